@@ -6,12 +6,24 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
+	"strings"
 
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/geojson"
+	"github.com/roboticeyes/gorex/encoding/gltf"
+	"github.com/roboticeyes/gorex/encoding/obj"
+	"github.com/roboticeyes/gorex/encoding/ply"
 	"github.com/roboticeyes/gorex/encoding/rex"
+	"github.com/roboticeyes/gorex/encoding/stl"
+	"github.com/roboticeyes/gorex/encoding/xyz"
 )
 
 var (
@@ -33,19 +45,37 @@ actions:
   rxi -v                    prints version
   rxi help                  print this help
 
-  rxi "file.rex"            show all REX blocks
+  rxi "file.rex"            show all REX blocks (compressed .rex.gz and .rex.zst files are supported)
   rxi bbox "file.rex"       displays the bounding box of the rex file
+  rxi validate "file.rex"   checks the consistency of the rex file (exit code 1 if issues are found)
 
   rxi img ID "file.rex"     extract the given image and dump it to stdout (pipe to a viewer, e.g. | feh -)
   rxi mesh ID "file.rex"    extract the mesh block and dump it to stdout
   rxi lines ID "file.rex"   extract the lineset block and dump it to stdout
 
+  rxi export obj "file.rex" "out/"  exports the rex file as Wavefront OBJ incl. materials and textures
+  rxi export gltf "file.rex" "out/" exports the rex file as glTF 2.0 (.gltf and .bin)
+  rxi export glb "file.rex" "out/"  exports the rex file as binary glTF 2.0 (.glb)
+  rxi export stl "file.rex" "out/"  exports all meshes as one binary STL file
+  rxi export ply "file.rex" "out/"  exports all meshes (or all point lists) as one binary PLY file
+  rxi export xyz "file.rex" "out/"  exports all point lists as one XYZ text file (x y z r g b)
+  rxi export geojson "file.rex" "out/" exports all line sets and point lists as GeoJSON features
+
+  rxi import xyz [options] "file.xyz" "output.rex"
+                            imports a text point cloud, options (see rxi import xyz -h):
+                            -columns x,y,z,intensity,r,g,b  column mapping, default x,y,z(,r,g,b)
+                            -skip 1                         number of header lines
+                            -comment "//"                   prefix of comment lines, default #
+                            -delimiter ";"                  value delimiter (or tab), default whitespace, comma, semicolon
+                            -unit                           colors are given as 0..1 instead of 0..255
+                            -offset 500000,5000000,0        subtracted from the points, stored in the coordinate system
+
   rxi scale <factor> "input.rex" "output.rex" scales all mesh vertices by the given factor (e.g. 0.001)
 `
 
 // help prints the help text to stdout
 func help(exit int) {
-	fmt.Println(helpText)
+	fmt.Print(helpText)
 	os.Exit(exit)
 }
 
@@ -55,57 +85,85 @@ func openRexFile(rexFile string) {
 		panic(err)
 	}
 	r := bufio.NewReader(file)
-	d := rex.NewDecoder(r)
+	d := rex.NewDecoderWithOptions(r, rex.DecoderOptions{
+		Warn:    func(err error) { fmt.Fprintln(os.Stderr, "WARNING:", err) },
+		Workers: runtime.NumCPU(),
+	})
+	defer d.Close()
 	rexHeader, rexContent, err = d.Decode()
-	if err != nil && err.Error() != "unexpected EOF" {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		fmt.Fprintln(os.Stderr, "WARNING: file is truncated,", err)
+	} else if err != nil {
 		panic(err)
 	}
 }
 
-// dumps the image to stdout (you can pipe it to an image viewer)
-func rexExtractImage(rexFile, idString string) {
-	openRexFile(rexFile)
+// reads the block with the given ID, only the block itself is decoded
+func readRexBlock(rexFile, idString string) rex.Block {
 	id, err := strconv.ParseUint(idString, 10, 64)
 	if err != nil {
 		panic(err)
 	}
-
-	for _, img := range rexContent.Images {
-		if img.ID == id {
-			binary.Write(os.Stdout, binary.LittleEndian, img.Data)
-		}
+	file, err := os.Open(rexFile)
+	if err != nil {
+		panic(err)
 	}
-}
-
-// dumps the mesh data block
-func rexShowMesh(rexFile, idString string) {
-	openRexFile(rexFile)
-	id, err := strconv.ParseUint(idString, 10, 64)
+	defer file.Close()
+	info, err := file.Stat()
 	if err != nil {
 		panic(err)
 	}
 
-	for _, mesh := range rexContent.Meshes {
-		if mesh.ID == id {
-			fmt.Println(mesh)
+	var block rex.Block
+	r, err := rex.NewReader(file, info.Size())
+	if errors.Is(err, rex.ErrCompressed) {
+		dec := rex.NewDecoder(file)
+		defer dec.Close()
+		block, err = findRexBlock(dec, id)
+	} else if err == nil {
+		block, err = r.ReadBlockByID(id)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return block
+}
+
+// iterates over the stream and decodes the block with the given ID
+func findRexBlock(d *rex.Decoder, id uint64) (rex.Block, error) {
+	for {
+		hdr, err := d.NextHeader()
+		if err == io.EOF {
+			return nil, fmt.Errorf("Block with ID %d not found", id)
+		} else if err != nil {
+			return nil, err
+		}
+		if hdr.ID == id {
+			return d.ReadBlock()
 		}
 	}
 }
 
-// dumps the lineset data blocks
-func rexShowLines(rexFile, idString string) {
-	openRexFile(rexFile)
-	id, err := strconv.ParseUint(idString, 10, 64)
-	if err != nil {
-		panic(err)
+// dumps the image to stdout (you can pipe it to an image viewer)
+func rexExtractImage(rexFile, idString string) {
+	if img, ok := readRexBlock(rexFile, idString).(*rex.Image); ok {
+		binary.Write(os.Stdout, binary.LittleEndian, img.Data)
 	}
+}
 
-	for _, lineset := range rexContent.LineSets {
-		if lineset.ID == id {
-			for _, p := range lineset.Points {
-				fmt.Printf("v %5.2f %5.2f %5.2f\n", p[0], p[1], p[2])
+// dumps the mesh data block
+func rexShowMesh(rexFile, idString string) {
+	if mesh, ok := readRexBlock(rexFile, idString).(*rex.Mesh); ok {
+		fmt.Println(mesh)
+	}
+}
 
-			}
+// dumps the lineset data blocks
+func rexShowLines(rexFile, idString string) {
+	if lineset, ok := readRexBlock(rexFile, idString).(*rex.LineSet); ok {
+		for _, p := range lineset.Points {
+			fmt.Printf("v %5.2f %5.2f %5.2f\n", p[0], p[1], p[2])
 		}
 	}
 }
@@ -175,6 +233,7 @@ func rexInfo(rexFile string) {
 	openRexFile(rexFile)
 
 	fmt.Println(rexHeader)
+	fmt.Println("Coordinate system:", rexHeader.CoordinateSystem)
 
 	// Meshes
 	if len(rexContent.Meshes) > 0 {
@@ -213,9 +272,9 @@ func rexInfo(rexFile string) {
 		fmt.Printf("%10s %8s %12s\n", "ID", "Compression", "Bytes")
 		for _, img := range rexContent.Images {
 			compression := "raw"
-			if img.Compression == 1 {
+			if img.Compression == rex.Jpeg {
 				compression = "jpg"
-			} else if img.Compression == 2 {
+			} else if img.Compression == rex.Png {
 				compression = "png"
 			}
 			fmt.Printf("%10d %11s %12d\n", img.ID, compression, len(img.Data))
@@ -240,6 +299,16 @@ func rexInfo(rexFile string) {
 		}
 	}
 
+	// Texts
+	if len(rexContent.Texts) > 0 {
+		fmt.Printf("Texts (%d)\n", len(rexContent.Texts))
+		fmt.Printf("%10s %21s %6s %s\n", "ID", "Position", "Size", "Text")
+		for _, t := range rexContent.Texts {
+			fmt.Printf("%10d [%+.2f, %+.2f, %+.2f] %6.1f %s\n", t.ID,
+				t.Position.X(), t.Position.Y(), t.Position.Z(), t.FontSize, t.Text)
+		}
+	}
+
 	// SceneNodes
 	if len(rexContent.SceneNodes) > 0 {
 		fmt.Printf("SceneNodes (%d)\n", len(rexContent.SceneNodes))
@@ -253,9 +322,156 @@ func rexInfo(rexFile string) {
 		}
 	}
 
-	if rexContent.UnknownBlocks > 0 {
-		fmt.Printf("Unknown blocks (%d)\n", rexContent.UnknownBlocks)
+	if len(rexContent.UnknownBlocks) > 0 {
+		fmt.Printf("Unknown blocks (%d)\n", len(rexContent.UnknownBlocks))
+		fmt.Printf("%10s %8s %8s %12s\n", "ID", "Type", "Version", "Bytes")
+		for _, b := range rexContent.UnknownBlocks {
+			fmt.Printf("%10d %8d %8d %12d\n", b.Header.ID, b.Header.Type, b.Header.Version, len(b.Payload))
+		}
+	}
+}
+
+func rexValidate(rexFile string) {
+	openRexFile(rexFile)
+
+	issues := rex.Validate(*rexContent)
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	if len(issues) > 0 {
+		fmt.Printf("%d issues found\n", len(issues))
+		os.Exit(1)
 	}
+	fmt.Println("No issues found")
+}
+
+// exports the rex file into the given directory, the output file is named like the input file
+func rexExport(format, rexFile, dir string) {
+	openRexFile(rexFile)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		panic(err)
+	}
+	base := filepath.Base(rexFile)
+	for ext := filepath.Ext(base); ext != ""; ext = filepath.Ext(base) {
+		base = strings.TrimSuffix(base, ext)
+	}
+
+	var err error
+	var output string
+	switch format {
+	case "obj":
+		output = filepath.Join(dir, base+".obj")
+		err = obj.WriteFile(output, *rexContent)
+	case "gltf", "glb":
+		output = filepath.Join(dir, base+"."+format)
+		err = gltf.WriteFile(output, *rexContent)
+	case "ply":
+		output = filepath.Join(dir, base+".ply")
+		err = ply.WriteFile(output, *rexContent)
+	case "stl":
+		output = filepath.Join(dir, base+".stl")
+		err = stl.WriteFile(output, *rexContent)
+	case "xyz":
+		output = filepath.Join(dir, base+".xyz")
+		err = xyz.WriteFile(output, *rexContent)
+	case "geojson":
+		output = filepath.Join(dir, base+".geojson")
+		err = geojson.WriteFile(output, *rexContent)
+	default:
+		fmt.Fprintf(os.Stderr, "Unsupported export format %s\n", format)
+		os.Exit(1)
+	}
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Successfully exported to %s\n", output)
+}
+
+// imports the given text point cloud, the point lists are streamed into the output file
+func rexImport(format string, args []string) {
+
+	if format != "xyz" {
+		fmt.Fprintf(os.Stderr, "Unsupported import format %s\n", format)
+		os.Exit(1)
+	}
+
+	flags := flag.NewFlagSet("import xyz", flag.ExitOnError)
+	columns := flags.String("columns", "", "comma separated columns, e.g. x,y,z,intensity,r,g,b (default x,y,z(,r,g,b))")
+	skip := flags.Int("skip", 0, "number of header lines")
+	comment := flags.String("comment", "#", "prefix of comment lines")
+	delimiter := flags.String("delimiter", "", "value delimiter, e.g. , or tab (default whitespace, comma and semicolon)")
+	unit := flags.Bool("unit", false, "colors are given as 0..1 instead of 0..255")
+	offset := flags.String("offset", "", "offset x,y,z which is subtracted and stored in the coordinate system")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		help(1)
+	}
+	input, output := flags.Arg(0), flags.Arg(1)
+
+	opts := xyz.DecoderOptions{SkipLines: *skip, Comment: *comment, UnitColors: *unit}
+	if *columns != "" {
+		opts.Columns = xyz.ParseColumns(*columns)
+	}
+	switch {
+	case *delimiter == "tab":
+		opts.Delimiter = '\t'
+	case len([]rune(*delimiter)) == 1:
+		opts.Delimiter = []rune(*delimiter)[0]
+	case *delimiter != "":
+		fmt.Fprintf(os.Stderr, "Invalid delimiter %q\n", *delimiter)
+		os.Exit(1)
+	}
+	if *offset != "" {
+		values := strings.Split(*offset, ",")
+		if len(values) != 3 {
+			fmt.Fprintf(os.Stderr, "Invalid offset %q\n", *offset)
+			os.Exit(1)
+		}
+		for i, v := range values {
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				panic(err)
+			}
+			opts.Offset[i] = f
+		}
+	}
+
+	in, err := os.Open(input)
+	if err != nil {
+		panic(err)
+	}
+	defer in.Close()
+	out, err := os.Create(output)
+	if err != nil {
+		panic(err)
+	}
+	defer out.Close()
+
+	dec := xyz.NewDecoderWithOptions(in, opts)
+	enc := rex.NewStreamEncoder(out)
+	cs := rex.DefaultCoordinateSystem()
+	cs.Offset = dec.CoordinateSystem().Offset
+	if err := enc.SetCoordinateSystem(cs); err != nil {
+		panic(err)
+	}
+	points := 0
+	for {
+		pl, err := dec.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			panic(err)
+		}
+		if err := enc.WriteBlock(pl); err != nil {
+			panic(err)
+		}
+		points += len(pl.Points)
+	}
+	if err := enc.Close(); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Successfully imported %d points to %s\n", points, output)
 }
 
 func rexScaleVertices(factor float32, input, output string) {
@@ -301,6 +517,8 @@ func main() {
 		fmt.Printf("rxi v%s-%s\n", Version, Build)
 	case "bbox":
 		rexBbox(os.Args[2])
+	case "validate":
+		rexValidate(os.Args[2])
 	case "translate":
 		rexTranslate(os.Args[2], 2200, -125, 1800, "spring_infra.rex")
 	case "img":
@@ -309,6 +527,16 @@ func main() {
 		rexShowMesh(os.Args[3], os.Args[2])
 	case "lines":
 		rexShowLines(os.Args[3], os.Args[2])
+	case "export":
+		if len(os.Args) < 5 {
+			help(1)
+		}
+		rexExport(os.Args[2], os.Args[3], os.Args[4])
+	case "import":
+		if len(os.Args) < 5 {
+			help(1)
+		}
+		rexImport(os.Args[2], os.Args[3:])
 	case "scale":
 		factor, err := strconv.ParseFloat(os.Args[2], 64)
 		if err != nil {
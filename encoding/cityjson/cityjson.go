@@ -0,0 +1,80 @@
+// Package cityjson reads CityJSON city models into REX meshes and materials
+package cityjson
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+)
+
+// cityJSON is the root object of a CityJSON file, only the members which are
+// required for the geometry are read
+type cityJSON struct {
+	Type        string                `json:"type"`
+	Version     string                `json:"version"`
+	Transform   *transform            `json:"transform"`
+	Metadata    metadata              `json:"metadata"`
+	CityObjects map[string]cityObject `json:"CityObjects"`
+	Vertices    [][3]float64          `json:"vertices"`
+}
+
+type transform struct {
+	Scale     [3]float64 `json:"scale"`
+	Translate [3]float64 `json:"translate"`
+}
+
+type metadata struct {
+	ReferenceSystem string `json:"referenceSystem"`
+}
+
+type cityObject struct {
+	Type     string     `json:"type"`
+	Geometry []geometry `json:"geometry"`
+}
+
+// geometry is a CityJSON geometry, the structure of the boundaries and the
+// semantic values depends on the type
+type geometry struct {
+	Type       string          `json:"type"`
+	LoD        json.RawMessage `json:"lod"` // number (1.0) or string (1.1)
+	Boundaries json.RawMessage `json:"boundaries"`
+	Semantics  *semantics      `json:"semantics"`
+}
+
+type semantics struct {
+	Surfaces []struct {
+		Type string `json:"type"`
+	} `json:"surfaces"`
+	Values json.RawMessage `json:"values"`
+}
+
+// lod returns the level of detail as string (e.g. 2 or 2.2)
+func (g geometry) lod() string {
+	var s string
+	if err := json.Unmarshal(g.LoD, &s); err == nil {
+		return s
+	}
+	var f float64
+	if err := json.Unmarshal(g.LoD, &f); err == nil {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return ""
+}
+
+// supported reference system notations, e.g.
+// https://www.opengis.net/def/crs/EPSG/0/7415 (1.1) and urn:ogc:def:crs:EPSG::7415 (1.0)
+var epsgReference = regexp.MustCompile(`EPSG(?:/\d+/|:[\d.]*:)(\d+)$`)
+
+// srid returns the EPSG code of the reference system, 0 if unknown
+func srid(referenceSystem string) uint32 {
+
+	m := epsgReference.FindStringSubmatch(referenceSystem)
+	if m == nil {
+		return 0
+	}
+	srid, err := strconv.ParseUint(m[1], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(srid)
+}
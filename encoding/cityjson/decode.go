@@ -0,0 +1,356 @@
+package cityjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/roboticeyes/gorex/encoding/internal/triangulate"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// DecoderOptions control the CityJSON import
+type DecoderOptions struct {
+	// LoD selects the level of detail (e.g. 2.2), if empty the highest level
+	// of detail of every city object is used
+	LoD string
+}
+
+// Decoder reads a CityJSON file (version 1.0 to 2.0). The MultiSurface,
+// CompositeSurface, Solid, MultiSolid and CompositeSolid geometries of every
+// city object are triangulated (incl. holes) and converted into meshes which
+// are named by the city object ID. Every semantic surface type (e.g.
+// RoofSurface, WallSurface, GroundSurface) gets its own material, therefore a
+// city object results in one mesh per surface type.
+//
+// The vertices are stored relative to the translation of the transform, which
+// is stored as offset of the REX coordinate system together with the EPSG code
+// of the reference system.
+type Decoder struct {
+	r    io.Reader
+	opts DecoderOptions
+}
+
+// NewDecoder creates a new CityJSON decoder
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// NewDecoderWithOptions creates a new CityJSON decoder with the given options
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	return &Decoder{r: r, opts: opts}
+}
+
+// ReadFile reads the given CityJSON file
+func ReadFile(name string) (*rex.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewDecoder(f).Decode()
+}
+
+// surfaceColors are the diffuse colors of the semantic surface types, other types use the REX default
+var surfaceColors = map[string]mgl32.Vec3{
+	"RoofSurface":   {0.7, 0.25, 0.2},
+	"WallSurface":   {0.9, 0.88, 0.8},
+	"GroundSurface": {0.45, 0.45, 0.45},
+}
+
+// surface is a polygon with its semantic surface type (empty if not available)
+type surface struct {
+	rings        [][]int
+	semanticType string
+}
+
+type decoder struct {
+	opts      DecoderOptions
+	file      *rex.File
+	vertices  []mgl64.Vec3 // relative to the offset of the coordinate system
+	materials map[string]uint64
+	id        uint64
+}
+
+// Decode reads the CityJSON file and returns the meshes and materials
+func (dec *Decoder) Decode() (*rex.File, error) {
+
+	var cj cityJSON
+	if err := json.NewDecoder(dec.r).Decode(&cj); err != nil {
+		return nil, fmt.Errorf("Reading CityJSON failed: %w", err)
+	}
+	if cj.Type != "CityJSON" {
+		return nil, fmt.Errorf("Not a CityJSON file")
+	}
+
+	d := &decoder{opts: dec.opts, file: &rex.File{}, materials: make(map[string]uint64)}
+	d.readVertices(cj)
+	if srid := srid(cj.Metadata.ReferenceSystem); srid != 0 {
+		d.file.CoordinateSystem.SRID = srid
+		d.file.CoordinateSystem.Authority = "EPSG"
+	}
+
+	ids := make([]string, 0, len(cj.CityObjects))
+	for id := range cj.CityObjects {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := d.readCityObject(id, cj.CityObjects[id]); err != nil {
+			return nil, fmt.Errorf("City object %s: %w", id, err)
+		}
+	}
+	return d.file, nil
+}
+
+func (d *decoder) nextID() uint64 {
+	d.id++
+	return d.id
+}
+
+// readVertices applies the transform. The vertices are relative to the
+// translation (or the rounded minimum if there is no transform), which is the
+// offset of the coordinate system.
+func (d *decoder) readVertices(cj cityJSON) {
+
+	scale := [3]float64{1, 1, 1}
+	var origin [3]float64
+	if cj.Transform != nil {
+		scale = cj.Transform.Scale
+		origin = cj.Transform.Translate
+	} else if len(cj.Vertices) > 0 {
+		origin = cj.Vertices[0]
+		for _, v := range cj.Vertices {
+			for i := 0; i < 3; i++ {
+				origin[i] = math.Min(origin[i], v[i])
+			}
+		}
+		for i := range origin {
+			origin[i] = math.Floor(origin[i])
+		}
+	}
+
+	// the offset is stored as float32, the rounding error is added to the vertices
+	var rest [3]float64
+	for i := 0; i < 3; i++ {
+		d.file.CoordinateSystem.Offset[i] = float32(origin[i])
+		rest[i] = origin[i] - float64(float32(origin[i]))
+	}
+
+	d.vertices = make([]mgl64.Vec3, len(cj.Vertices))
+	for j, v := range cj.Vertices {
+		for i := 0; i < 3; i++ {
+			if cj.Transform != nil {
+				d.vertices[j][i] = v[i]*scale[i] + rest[i]
+			} else {
+				d.vertices[j][i] = v[i] - float64(d.file.CoordinateSystem.Offset[i])
+			}
+		}
+	}
+}
+
+// selectGeometries returns the geometries of the configured or the highest level of detail
+func (d *decoder) selectGeometries(geometries []geometry) []geometry {
+
+	lod := d.opts.LoD
+	if lod == "" {
+		max := math.Inf(-1)
+		for _, g := range geometries {
+			if v, err := strconv.ParseFloat(g.lod(), 64); err == nil && v > max {
+				max, lod = v, g.lod()
+			}
+		}
+	}
+	var res []geometry
+	for _, g := range geometries {
+		if g.lod() == lod {
+			res = append(res, g)
+		}
+	}
+	return res
+}
+
+// readCityObject creates one mesh per semantic surface type
+func (d *decoder) readCityObject(id string, o cityObject) error {
+
+	var surfaces []surface
+	for _, g := range d.selectGeometries(o.Geometry) {
+		s, err := readSurfaces(g)
+		if err != nil {
+			return err
+		}
+		surfaces = append(surfaces, s...)
+	}
+
+	var types []string
+	meshes := make(map[string]*rex.Mesh)
+	for _, s := range surfaces {
+		m, ok := meshes[s.semanticType]
+		if !ok {
+			m = &rex.Mesh{Name: id}
+			meshes[s.semanticType] = m
+			types = append(types, s.semanticType)
+		}
+		if err := d.addSurface(m, s); err != nil {
+			return err
+		}
+	}
+	for _, t := range types {
+		if m := meshes[t]; len(m.Triangles) > 0 {
+			m.MaterialID = d.materialID(t)
+			m.ID = d.nextID()
+			d.file.Meshes = append(d.file.Meshes, *m)
+		}
+	}
+	return nil
+}
+
+// addSurface triangulates the surface, every surface has its own vertices with the surface normal
+func (d *decoder) addSurface(m *rex.Mesh, s surface) error {
+
+	rings := make([][]mgl64.Vec3, len(s.rings))
+	for i, r := range s.rings {
+		rings[i] = make([]mgl64.Vec3, len(r))
+		for j, idx := range r {
+			if idx < 0 || idx >= len(d.vertices) {
+				return fmt.Errorf("invalid vertex index %d", idx)
+			}
+			rings[i][j] = d.vertices[idx]
+		}
+	}
+	normal, triangles := triangulate.Polygon(rings)
+	if len(triangles) == 0 {
+		return nil
+	}
+
+	offset := uint32(len(m.Coords))
+	n := mgl32.Vec3{float32(normal[0]), float32(normal[1]), float32(normal[2])}
+	for _, r := range rings {
+		for _, v := range r {
+			m.Coords = append(m.Coords, mgl32.Vec3{float32(v[0]), float32(v[1]), float32(v[2])})
+			m.Normals = append(m.Normals, n)
+		}
+	}
+	for _, t := range triangles {
+		m.Triangles = append(m.Triangles, rex.Triangle{
+			V0: offset + uint32(t[0]),
+			V1: offset + uint32(t[1]),
+			V2: offset + uint32(t[2]),
+		})
+	}
+	return nil
+}
+
+// materialID returns the material of the semantic surface type, the material is created on first use
+func (d *decoder) materialID(semanticType string) uint64 {
+
+	if id, ok := d.materials[semanticType]; ok {
+		return id
+	}
+	mat := rex.NewMaterial(d.nextID())
+	if c, ok := surfaceColors[semanticType]; ok {
+		mat.KdRgb = c
+	}
+	d.materials[semanticType] = mat.ID
+	d.file.Materials = append(d.file.Materials, mat)
+	return mat.ID
+}
+
+// readSurfaces returns all surfaces of the geometry with their semantic surface type
+func readSurfaces(g geometry) ([]surface, error) {
+
+	var semanticTypes []string
+	var values json.RawMessage
+	if g.Semantics != nil {
+		for _, s := range g.Semantics.Surfaces {
+			semanticTypes = append(semanticTypes, s.Type)
+		}
+		values = g.Semantics.Values
+	}
+	semanticType := func(v *int) string {
+		if v == nil || *v < 0 || *v >= len(semanticTypes) {
+			return ""
+		}
+		return semanticTypes[*v]
+	}
+
+	var res []surface
+	switch g.Type {
+	case "MultiSurface", "CompositeSurface":
+		var boundaries [][][]int
+		var sem []*int
+		if err := unmarshal(g, &boundaries, values, &sem); err != nil {
+			return nil, err
+		}
+		for i, rings := range boundaries {
+			res = append(res, surface{rings: rings, semanticType: semanticType(valueAt(sem, i))})
+		}
+	case "Solid":
+		var boundaries [][][][]int
+		var sem [][]*int
+		if err := unmarshal(g, &boundaries, values, &sem); err != nil {
+			return nil, err
+		}
+		for i, shell := range boundaries {
+			for j, rings := range shell {
+				res = append(res, surface{rings: rings, semanticType: semanticType(valueAt(sliceAt(sem, i), j))})
+			}
+		}
+	case "MultiSolid", "CompositeSolid":
+		var boundaries [][][][][]int
+		var sem [][][]*int
+		if err := unmarshal(g, &boundaries, values, &sem); err != nil {
+			return nil, err
+		}
+		for i, solid := range boundaries {
+			var solidSem [][]*int
+			if i < len(sem) {
+				solidSem = sem[i]
+			}
+			for j, shell := range solid {
+				for k, rings := range shell {
+					res = append(res, surface{rings: rings, semanticType: semanticType(valueAt(sliceAt(solidSem, j), k))})
+				}
+			}
+		}
+	default:
+		// points, lines and geometry instances are not supported
+	}
+	return res, nil
+}
+
+// unmarshal reads the boundaries and the semantic values of the geometry
+func unmarshal(g geometry, boundaries interface{}, values json.RawMessage, sem interface{}) error {
+
+	if err := json.Unmarshal(g.Boundaries, boundaries); err != nil {
+		return fmt.Errorf("invalid %s boundaries: %w", g.Type, err)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(values, sem); err != nil {
+		return fmt.Errorf("invalid %s semantic values: %w", g.Type, err)
+	}
+	return nil
+}
+
+func valueAt(values []*int, i int) *int {
+	if i < len(values) {
+		return values[i]
+	}
+	return nil
+}
+
+func sliceAt(values [][]*int, i int) []*int {
+	if i < len(values) {
+		return values[i]
+	}
+	return nil
+}
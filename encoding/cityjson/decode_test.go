@@ -0,0 +1,147 @@
+package cityjson
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+const cityModel = `{
+  "type": "CityJSON",
+  "version": "1.1",
+  "transform": {"scale": [0.001, 0.001, 0.001], "translate": [84000.5, 446000, 0]},
+  "metadata": {"referenceSystem": "https://www.opengis.net/def/crs/EPSG/0/7415"},
+  "CityObjects": {
+    "terrain": {
+      "type": "TINRelief",
+      "geometry": [{
+        "type": "MultiSurface",
+        "lod": "1",
+        "boundaries": [[[8, 9, 10, 11], [12, 13, 14, 15]]]
+      }]
+    },
+    "building": {
+      "type": "Building",
+      "geometry": [{
+        "type": "MultiSurface",
+        "lod": "1",
+        "boundaries": [[[4, 5, 6, 7]]]
+      }, {
+        "type": "Solid",
+        "lod": "2",
+        "boundaries": [[
+          [[0, 3, 2, 1]], [[4, 5, 6, 7]],
+          [[0, 1, 5, 4]], [[1, 2, 6, 5]], [[2, 3, 7, 6]], [[3, 0, 4, 7]]
+        ]],
+        "semantics": {
+          "surfaces": [{"type": "GroundSurface"}, {"type": "RoofSurface"}, {"type": "WallSurface"}],
+          "values": [[0, 1, 2, 2, 2, 2]]
+        }
+      }]
+    }
+  },
+  "vertices": [
+    [0, 0, 0], [10000, 0, 0], [10000, 10000, 0], [0, 10000, 0],
+    [0, 0, 10000], [10000, 0, 10000], [10000, 10000, 10000], [0, 10000, 10000],
+    [20000, 0, 0], [30000, 0, 0], [30000, 10000, 0], [20000, 10000, 0],
+    [22000, 2000, 0], [22000, 4000, 0], [24000, 4000, 0], [24000, 2000, 0]
+  ]
+}`
+
+func TestDecode(t *testing.T) {
+
+	f, err := NewDecoder(strings.NewReader(cityModel)).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	cs := f.CoordinateSystem
+	if cs.SRID != 7415 || cs.Authority != "EPSG" || cs.Offset != (mgl32.Vec3{84000.5, 446000, 0}) {
+		t.Errorf("Invalid coordinate system %v", cs)
+	}
+
+	if len(f.Meshes) != 4 || len(f.Materials) != 4 {
+		t.Fatalf("Expected 4 meshes and materials, got %d/%d", len(f.Meshes), len(f.Materials))
+	}
+	materials := make(map[uint64]rex.Material)
+	for _, m := range f.Materials {
+		materials[m.ID] = m
+	}
+
+	ground, roof, wall, terrain := f.Meshes[0], f.Meshes[1], f.Meshes[2], f.Meshes[3]
+	if ground.Name != "building" || roof.Name != "building" || wall.Name != "building" || terrain.Name != "terrain" {
+		t.Errorf("Invalid mesh names")
+	}
+	if len(ground.Triangles) != 2 || len(roof.Triangles) != 2 || len(wall.Triangles) != 8 || len(terrain.Triangles) != 8 {
+		t.Errorf("Invalid triangle count %d %d %d %d", len(ground.Triangles), len(roof.Triangles), len(wall.Triangles), len(terrain.Triangles))
+	}
+	if roof.Coords[2] != (mgl32.Vec3{10, 10, 10}) {
+		t.Errorf("Invalid roof vertex %v", roof.Coords[2])
+	}
+	if roof.Normals[0] != (mgl32.Vec3{0, 0, 1}) || ground.Normals[0] != (mgl32.Vec3{0, 0, -1}) {
+		t.Errorf("Invalid normals %v %v", roof.Normals[0], ground.Normals[0])
+	}
+	if materials[roof.MaterialID].KdRgb != surfaceColors["RoofSurface"] || materials[wall.MaterialID].KdRgb != surfaceColors["WallSurface"] {
+		t.Errorf("Invalid materials")
+	}
+	if materials[terrain.MaterialID].KdRgb != rex.NewMaterial(0).KdRgb {
+		t.Errorf("Surfaces w/o semantics require the default material")
+	}
+
+	// the orientation of the solid is kept
+	for _, tri := range wall.Triangles {
+		a, b, c := wall.Coords[tri.V0], wall.Coords[tri.V1], wall.Coords[tri.V2]
+		if b.Sub(a).Cross(c.Sub(a)).Dot(wall.Normals[tri.V0]) <= 0 {
+			t.Errorf("Invalid triangle orientation %v", tri)
+		}
+	}
+	if issues := rex.Validate(*f); len(issues) > 0 {
+		t.Errorf("Validation failed %v", issues)
+	}
+}
+
+func TestDecodeLoD(t *testing.T) {
+
+	f, err := NewDecoderWithOptions(strings.NewReader(cityModel), DecoderOptions{LoD: "1"}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.Meshes) != 2 || len(f.Materials) != 1 {
+		t.Fatalf("Expected 2 meshes and 1 material, got %d/%d", len(f.Meshes), len(f.Materials))
+	}
+	if f.Meshes[0].Name != "building" || len(f.Meshes[0].Triangles) != 2 {
+		t.Errorf("Invalid LoD 1 building")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+
+	tests := []string{
+		`{"type": "Feature"}`,
+		`{"type": "CityJSON", "CityObjects": {"a": {"geometry": [{"type": "MultiSurface", "lod": 1, "boundaries": [[[0, 1, 2]]]}]}}, "vertices": [[0, 0, 0]]}`,
+		`{"type": "CityJSON", "CityObjects": {"a": {"geometry": [{"type": "Solid", "lod": 1, "boundaries": [[0, 1, 2]]}]}}}`,
+		`{"type": `,
+	}
+	for _, input := range tests {
+		if _, err := NewDecoder(strings.NewReader(input)).Decode(); err == nil {
+			t.Errorf("Expected error for %s", input)
+		}
+	}
+}
+
+func TestSRID(t *testing.T) {
+
+	tests := map[string]uint32{
+		"https://www.opengis.net/def/crs/EPSG/0/7415": 7415,
+		"urn:ogc:def:crs:EPSG::2355":                  2355,
+		"urn:ogc:def:crs:EPSG:9.8.15:7415":            7415,
+		"":                                            0,
+	}
+	for reference, expected := range tests {
+		if s := srid(reference); s != expected {
+			t.Errorf("Expected %d for %s, got %d", expected, reference, s)
+		}
+	}
+}
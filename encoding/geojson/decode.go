@@ -0,0 +1,287 @@
+package geojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// DecoderOptions control the GeoJSON import
+type DecoderOptions struct {
+	// Origin is subtracted from all coordinates and stored as offset of the
+	// coordinate system. Geographic and projected coordinates are too large
+	// for the float32 precision of REX, therefore the origin should be close
+	// to the data.
+	Origin [3]float64
+	// AutoOrigin uses the minimum of all coordinates (rounded down to full
+	// units) as origin instead of Origin
+	AutoOrigin bool
+	// LineColor is used for line sets w/o stroke property, if zero white is used
+	LineColor mgl32.Vec4
+}
+
+// Decoder reads a GeoJSON file. LineString and MultiLineString geometries are
+// converted into line sets, the simplestyle properties stroke and
+// stroke-opacity are used as color. All Point and MultiPoint geometries are
+// combined into one point list, colored by marker-color if any feature has
+// this property. Other geometries are skipped.
+//
+// The coordinate system is taken from the crs member (GeoJSON 2008), if not
+// available WGS84 is used.
+type Decoder struct {
+	r    io.Reader
+	opts DecoderOptions
+
+	lines       []line
+	points      [][3]float64
+	pointColors []*mgl32.Vec3
+	id          uint64
+}
+
+// line is a line string with absolute coordinates
+type line struct {
+	positions [][3]float64
+	color     mgl32.Vec4
+}
+
+// NewDecoder creates a new GeoJSON decoder which computes the origin automatically
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, DecoderOptions{AutoOrigin: true})
+}
+
+// NewDecoderWithOptions creates a new GeoJSON decoder with the given options
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	if opts.LineColor == (mgl32.Vec4{}) {
+		opts.LineColor = mgl32.Vec4{1, 1, 1, 1}
+	}
+	return &Decoder{r: r, opts: opts}
+}
+
+// ReadFile reads the given GeoJSON file, the origin is computed automatically
+func ReadFile(name string) (*rex.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewDecoder(f).Decode()
+}
+
+// Decode reads the GeoJSON file and returns the line sets and the point list
+func (dec *Decoder) Decode() (*rex.File, error) {
+
+	var root object
+	if err := json.NewDecoder(dec.r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("Reading GeoJSON failed: %w", err)
+	}
+	if err := dec.readObject(root, nil); err != nil {
+		return nil, err
+	}
+
+	srid := root.CRS.srid()
+	if srid == 0 {
+		srid = WGS84
+	}
+	origin := dec.origin()
+	f := &rex.File{CoordinateSystem: rex.CoordinateSystem{
+		SRID:      srid,
+		Authority: "EPSG",
+		Offset:    mgl32.Vec3{float32(origin[0]), float32(origin[1]), float32(origin[2])},
+	}}
+	// the offset is stored as float32, therefore the rounded value is subtracted
+	for i := 0; i < 3; i++ {
+		origin[i] = float64(f.CoordinateSystem.Offset[i])
+	}
+
+	for _, l := range dec.lines {
+		ls := rex.LineSet{ID: dec.nextID(), Colors: l.color, Points: make([]mgl32.Vec3, len(l.positions))}
+		for i, p := range l.positions {
+			ls.Points[i] = relative(p, origin)
+		}
+		f.LineSets = append(f.LineSets, ls)
+	}
+
+	if len(dec.points) > 0 {
+		pl := rex.PointList{ID: dec.nextID(), Points: make([]mgl32.Vec3, len(dec.points))}
+		for i, p := range dec.points {
+			pl.Points[i] = relative(p, origin)
+		}
+		if dec.hasPointColors() {
+			pl.Colors = make([]mgl32.Vec3, len(dec.points))
+			for i, c := range dec.pointColors {
+				pl.Colors[i] = mgl32.Vec3{1, 1, 1}
+				if c != nil {
+					pl.Colors[i] = *c
+				}
+			}
+		}
+		f.PointLists = append(f.PointLists, pl)
+	}
+	return f, nil
+}
+
+func (dec *Decoder) nextID() uint64 {
+	dec.id++
+	return dec.id
+}
+
+func relative(p, origin [3]float64) mgl32.Vec3 {
+	return mgl32.Vec3{float32(p[0] - origin[0]), float32(p[1] - origin[1]), float32(p[2] - origin[2])}
+}
+
+// origin returns the configured origin or the rounded minimum of all coordinates
+func (dec *Decoder) origin() [3]float64 {
+
+	if !dec.opts.AutoOrigin {
+		return dec.opts.Origin
+	}
+	min := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	update := func(p [3]float64) {
+		for i := 0; i < 3; i++ {
+			min[i] = math.Min(min[i], p[i])
+		}
+	}
+	for _, l := range dec.lines {
+		for _, p := range l.positions {
+			update(p)
+		}
+	}
+	for _, p := range dec.points {
+		update(p)
+	}
+	if math.IsInf(min[0], 1) {
+		return [3]float64{}
+	}
+	for i := range min {
+		min[i] = math.Floor(min[i])
+	}
+	return min
+}
+
+func (dec *Decoder) hasPointColors() bool {
+	for _, c := range dec.pointColors {
+		if c != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// readObject reads the object recursively, the properties of the feature are
+// passed to the geometries
+func (dec *Decoder) readObject(o object, props map[string]interface{}) error {
+
+	switch o.Type {
+	case "FeatureCollection":
+		for i, f := range o.Features {
+			if err := dec.readObject(f, nil); err != nil {
+				return fmt.Errorf("feature %d: %w", i, err)
+			}
+		}
+	case "Feature":
+		if o.Geometry != nil {
+			return dec.readObject(*o.Geometry, o.Properties)
+		}
+	case "GeometryCollection":
+		for _, g := range o.Geometries {
+			if err := dec.readObject(g, props); err != nil {
+				return err
+			}
+		}
+	case "Point":
+		var p []float64
+		if err := json.Unmarshal(o.Coordinates, &p); err != nil {
+			return fmt.Errorf("invalid Point coordinates: %w", err)
+		}
+		return dec.addPoints([][]float64{p}, props)
+	case "MultiPoint":
+		var p [][]float64
+		if err := json.Unmarshal(o.Coordinates, &p); err != nil {
+			return fmt.Errorf("invalid MultiPoint coordinates: %w", err)
+		}
+		return dec.addPoints(p, props)
+	case "LineString":
+		var l [][]float64
+		if err := json.Unmarshal(o.Coordinates, &l); err != nil {
+			return fmt.Errorf("invalid LineString coordinates: %w", err)
+		}
+		return dec.addLines([][][]float64{l}, props)
+	case "MultiLineString":
+		var l [][][]float64
+		if err := json.Unmarshal(o.Coordinates, &l); err != nil {
+			return fmt.Errorf("invalid MultiLineString coordinates: %w", err)
+		}
+		return dec.addLines(l, props)
+	case "Polygon", "MultiPolygon":
+		// not supported
+	default:
+		return fmt.Errorf("invalid GeoJSON type %q", o.Type)
+	}
+	return nil
+}
+
+// toPosition converts a GeoJSON position with 2 or 3 values
+func toPosition(p []float64) ([3]float64, error) {
+	if len(p) < 2 {
+		return [3]float64{}, fmt.Errorf("position requires at least 2 values, got %d", len(p))
+	}
+	pos := [3]float64{p[0], p[1], 0}
+	if len(p) > 2 {
+		pos[2] = p[2]
+	}
+	return pos, nil
+}
+
+func (dec *Decoder) addPoints(points [][]float64, props map[string]interface{}) error {
+
+	var color *mgl32.Vec3
+	if s, ok := props["marker-color"].(string); ok {
+		if c, ok := parseColor(s); ok {
+			color = &c
+		}
+	}
+	for _, p := range points {
+		pos, err := toPosition(p)
+		if err != nil {
+			return err
+		}
+		dec.points = append(dec.points, pos)
+		dec.pointColors = append(dec.pointColors, color)
+	}
+	return nil
+}
+
+func (dec *Decoder) addLines(lines [][][]float64, props map[string]interface{}) error {
+
+	color := dec.opts.LineColor
+	if s, ok := props["stroke"].(string); ok {
+		if c, ok := parseColor(s); ok {
+			color = c.Vec4(color[3])
+		}
+	}
+	if opacity, ok := props["stroke-opacity"].(float64); ok {
+		color[3] = float32(opacity)
+	}
+
+	for _, l := range lines {
+		if len(l) < 2 {
+			continue
+		}
+		res := line{color: color, positions: make([][3]float64, len(l))}
+		for i, p := range l {
+			pos, err := toPosition(p)
+			if err != nil {
+				return err
+			}
+			res.positions[i] = pos
+		}
+		dec.lines = append(dec.lines, res)
+	}
+	return nil
+}
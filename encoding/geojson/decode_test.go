@@ -0,0 +1,136 @@
+package geojson
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const network = `{
+  "type": "FeatureCollection",
+  "crs": {"type": "name", "properties": {"name": "urn:ogc:def:crs:EPSG::25832"}},
+  "features": [
+    {
+      "type": "Feature",
+      "properties": {"stroke": "#ff0000", "stroke-opacity": 0.5},
+      "geometry": {"type": "LineString", "coordinates": [[500010.5, 5000020, 300], [500011.5, 5000021, 301]]}
+    },
+    {
+      "type": "Feature",
+      "properties": {"stroke": "#00f"},
+      "geometry": {"type": "MultiLineString", "coordinates": [
+        [[500000, 5000000], [500001, 5000001]],
+        [[500002, 5000002], [500003, 5000003], [500004, 5000004]]
+      ]}
+    },
+    {
+      "type": "Feature",
+      "properties": {"marker-color": "#00ff00"},
+      "geometry": {"type": "Point", "coordinates": [500005, 5000005, 299.5]}
+    },
+    {
+      "type": "Feature",
+      "properties": null,
+      "geometry": {"type": "GeometryCollection", "geometries": [
+        {"type": "MultiPoint", "coordinates": [[500006, 5000006], [500007, 5000007]]},
+        {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}
+      ]}
+    }
+  ]
+}`
+
+func TestDecode(t *testing.T) {
+
+	f, err := NewDecoder(strings.NewReader(network)).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	cs := f.CoordinateSystem
+	if cs.SRID != 25832 || cs.Authority != "EPSG" || cs.Offset != (mgl32.Vec3{500000, 5000000, 0}) {
+		t.Errorf("Invalid coordinate system %v", cs)
+	}
+
+	if len(f.LineSets) != 3 {
+		t.Fatalf("Expected 3 line sets, got %d", len(f.LineSets))
+	}
+	if f.LineSets[0].Colors != (mgl32.Vec4{1, 0, 0, 0.5}) || f.LineSets[1].Colors != (mgl32.Vec4{0, 0, 1, 1}) {
+		t.Errorf("Invalid colors %v %v", f.LineSets[0].Colors, f.LineSets[1].Colors)
+	}
+	if f.LineSets[0].Points[0] != (mgl32.Vec3{10.5, 20, 300}) {
+		t.Errorf("Invalid point %v", f.LineSets[0].Points[0])
+	}
+	if len(f.LineSets[2].Points) != 3 || f.LineSets[2].Points[2] != (mgl32.Vec3{4, 4, 0}) {
+		t.Errorf("Invalid line set %v", f.LineSets[2].Points)
+	}
+
+	if len(f.PointLists) != 1 {
+		t.Fatalf("Expected 1 point list, got %d", len(f.PointLists))
+	}
+	pl := f.PointLists[0]
+	if pl.ID != 4 || len(pl.Points) != 3 || len(pl.Colors) != 3 {
+		t.Fatalf("Expected 3 colored points, got %d/%d", len(pl.Points), len(pl.Colors))
+	}
+	if pl.Points[0] != (mgl32.Vec3{5, 5, 299.5}) || pl.Colors[0] != (mgl32.Vec3{0, 1, 0}) || pl.Colors[1] != (mgl32.Vec3{1, 1, 1}) {
+		t.Errorf("Invalid points %v %v", pl.Points, pl.Colors)
+	}
+}
+
+func TestDecodeOrigin(t *testing.T) {
+
+	input := `{"type": "LineString", "coordinates": [[15.4395, 47.0707], [15.4401, 47.0712]]}`
+	dec := NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{
+		Origin:    [3]float64{15, 47, 0},
+		LineColor: mgl32.Vec4{0, 1, 0, 1},
+	})
+	f, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if f.CoordinateSystem.SRID != WGS84 || f.CoordinateSystem.Offset != (mgl32.Vec3{15, 47, 0}) {
+		t.Errorf("Invalid coordinate system %v", f.CoordinateSystem)
+	}
+	if len(f.LineSets) != 1 || f.LineSets[0].Colors != (mgl32.Vec4{0, 1, 0, 1}) {
+		t.Fatalf("Expected 1 green line set")
+	}
+	if p := f.LineSets[0].Points[1]; !p.ApproxEqual(mgl32.Vec3{0.4401, 0.0712, 0}) {
+		t.Errorf("Invalid point %v", p)
+	}
+	if len(f.PointLists) != 0 {
+		t.Errorf("Expected no point list")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+
+	tests := []string{
+		`{"type": "Point", "coordinates": [1]}`,
+		`{"type": "LineString", "coordinates": [1, 2]}`,
+		`{"type": "Unknown"}`,
+		`{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Point", "coordinates": "a"}}]}`,
+		`{"type": `,
+	}
+	for _, input := range tests {
+		if _, err := NewDecoder(strings.NewReader(input)).Decode(); err == nil {
+			t.Errorf("Expected error for %s", input)
+		}
+	}
+}
+
+func TestCRS(t *testing.T) {
+
+	tests := map[string]uint32{
+		"urn:ogc:def:crs:OGC:1.3:CRS84": WGS84,
+		"urn:ogc:def:crs:EPSG::31256":   31256,
+		"EPSG:3857":                     3857,
+		"unknown":                       0,
+	}
+	for name, srid := range tests {
+		c := &crs{Type: "name"}
+		c.Properties.Name = name
+		if c.srid() != srid {
+			t.Errorf("Expected %d for %s, got %d", srid, name, c.srid())
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package geojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/internal/coord"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// EncoderOptions control the GeoJSON export
+type EncoderOptions struct {
+	// Offset is added to all coordinates (e.g. the offset of the coordinate system)
+	Offset [3]float64
+	// SRID is written as crs member, if it is neither 0 nor WGS84
+	SRID uint32
+}
+
+// Encoder writes REX line sets as LineString features (incl. stroke and
+// stroke-opacity) and point lists as MultiPoint features. Colored point lists
+// are written as one MultiPoint feature per color (marker-color). The block ID
+// is used as feature ID, the features of colored point lists get the ID
+// <block ID>-<color index>.
+type Encoder struct {
+	w    io.Writer
+	opts EncoderOptions
+}
+
+// NewEncoder creates a new GeoJSON encoder
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// NewEncoderWithOptions creates a new GeoJSON encoder with the given options
+func NewEncoderWithOptions(w io.Writer, opts EncoderOptions) *Encoder {
+	return &Encoder{w: w, opts: opts}
+}
+
+// WriteFile writes the line sets and point lists of the REX file as GeoJSON.
+// The offset and the SRID of the coordinate system are used.
+func WriteFile(name string, f rex.File) error {
+
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	cs := f.CoordinateSystem
+	enc := NewEncoderWithOptions(out, EncoderOptions{
+		Offset: coord.Offset(cs.Offset),
+		SRID:   cs.SRID,
+	})
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// featureCollection, feature and geometry are the GeoJSON objects which are written
+type featureCollection struct {
+	Type     string    `json:"type"`
+	CRS      *crs      `json:"crs,omitempty"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id"`
+	Geometry   geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// Encode writes the line sets and point lists as feature collection
+func (enc *Encoder) Encode(f rex.File) error {
+
+	fc := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+	if enc.opts.SRID != 0 && enc.opts.SRID != WGS84 {
+		fc.CRS = newCRS(enc.opts.SRID)
+	}
+
+	for _, ls := range f.LineSets {
+		fc.Features = append(fc.Features, feature{
+			Type:     "Feature",
+			ID:       ls.ID,
+			Geometry: geometry{Type: "LineString", Coordinates: enc.positions(ls.Points)},
+			Properties: map[string]interface{}{
+				"stroke":         formatColor(ls.Colors.Vec3()),
+				"stroke-opacity": ls.Colors[3],
+			},
+		})
+	}
+
+	for _, pl := range f.PointLists {
+		if len(pl.Colors) != len(pl.Points) || len(pl.Colors) == 0 {
+			fc.Features = append(fc.Features, feature{
+				Type:       "Feature",
+				ID:         pl.ID,
+				Geometry:   geometry{Type: "MultiPoint", Coordinates: enc.positions(pl.Points)},
+				Properties: map[string]interface{}{},
+			})
+			continue
+		}
+
+		// group the points by their color
+		var colors []string
+		points := make(map[string][]mgl32.Vec3)
+		for i, p := range pl.Points {
+			c := formatColor(pl.Colors[i])
+			if _, ok := points[c]; !ok {
+				colors = append(colors, c)
+			}
+			points[c] = append(points[c], p)
+		}
+		for i, c := range colors {
+			fc.Features = append(fc.Features, feature{
+				Type:       "Feature",
+				ID:         fmt.Sprintf("%d-%d", pl.ID, i),
+				Geometry:   geometry{Type: "MultiPoint", Coordinates: enc.positions(points[c])},
+				Properties: map[string]interface{}{"marker-color": c},
+			})
+		}
+	}
+
+	return json.NewEncoder(enc.w).Encode(fc)
+}
+
+func (enc *Encoder) positions(points []mgl32.Vec3) []position {
+	positions := make([]position, len(points))
+	for i, p := range points {
+		positions[i] = position{v: p, offset: enc.opts.Offset}
+	}
+	return positions
+}
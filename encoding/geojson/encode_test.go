@@ -0,0 +1,161 @@
+package geojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+func TestEncode(t *testing.T) {
+
+	f := rex.File{
+		LineSets: []rex.LineSet{{ID: 1, Colors: mgl32.Vec4{1, 0, 0, 0.5}, Points: []mgl32.Vec3{{0, 0, 0}, {1.01, 2, 3}}}},
+		PointLists: []rex.PointList{
+			{ID: 2, Points: []mgl32.Vec3{{1, 2, 3}, {4, 5, 6}}},
+			{ID: 3, Points: []mgl32.Vec3{{7, 8, 9}, {1, 1, 1}, {2, 2, 2}}, Colors: []mgl32.Vec3{{0, 1, 0}, {1, 0, 0}, {0, 1, 0}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoderWithOptions(&buf, EncoderOptions{Offset: [3]float64{500000, 5000000, 0}, SRID: 25832})
+	if err := enc.Encode(f); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	var fc struct {
+		CRS      crs
+		Features []struct {
+			ID       interface{}
+			Geometry struct {
+				Type        string
+				Coordinates json.RawMessage
+			}
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if fc.CRS.srid() != 25832 {
+		t.Errorf("Invalid CRS %v", fc.CRS)
+	}
+	if len(fc.Features) != 4 {
+		t.Fatalf("Expected 4 features, got %d", len(fc.Features))
+	}
+
+	line := fc.Features[0]
+	if line.ID != 1.0 || line.Geometry.Type != "LineString" || string(line.Geometry.Coordinates) != "[[500000,5000000,0],[500001.01,5000002,3]]" {
+		t.Errorf("Invalid line %d %s %s", line.ID, line.Geometry.Type, line.Geometry.Coordinates)
+	}
+	if line.Properties["stroke"] != "#ff0000" || line.Properties["stroke-opacity"] != 0.5 {
+		t.Errorf("Invalid line properties %v", line.Properties)
+	}
+	if fc.Features[1].Geometry.Type != "MultiPoint" || fc.Features[1].ID != 2.0 {
+		t.Errorf("Invalid point geometry %v", fc.Features[1])
+	}
+
+	// colored points are grouped by color
+	green, red := fc.Features[2], fc.Features[3]
+	if green.ID != "3-0" || red.ID != "3-1" {
+		t.Errorf("Invalid feature IDs %v %v", green.ID, red.ID)
+	}
+	if green.Geometry.Type != "MultiPoint" || string(green.Geometry.Coordinates) != "[[500007,5000008,9],[500002,5000002,2]]" {
+		t.Errorf("Invalid geometry %s %s", green.Geometry.Type, green.Geometry.Coordinates)
+	}
+	if green.Properties["marker-color"] != "#00ff00" || red.Properties["marker-color"] != "#ff0000" {
+		t.Errorf("Invalid point properties %v %v", green.Properties, red.Properties)
+	}
+}
+
+func TestRoundtrip(t *testing.T) {
+
+	f := rex.File{
+		CoordinateSystem: rex.CoordinateSystem{SRID: 31256, Authority: "EPSG", Offset: mgl32.Vec3{-67000, 215000, 0}},
+		LineSets:         []rex.LineSet{{ID: 1, Colors: mgl32.Vec4{0, 0, 1, 1}, Points: []mgl32.Vec3{{1, 2, 3}, {4.5, 5, 6}}}},
+	}
+	var buf bytes.Buffer
+	enc := NewEncoderWithOptions(&buf, EncoderOptions{Offset: [3]float64{-67000, 215000, 0}, SRID: 31256})
+	if err := enc.Encode(f); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	dec := NewDecoderWithOptions(&buf, DecoderOptions{Origin: [3]float64{-67000, 215000, 0}})
+	res, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if res.CoordinateSystem != f.CoordinateSystem {
+		t.Errorf("Expected %v, got %v", f.CoordinateSystem, res.CoordinateSystem)
+	}
+	if len(res.LineSets) != 1 || res.LineSets[0].Colors != f.LineSets[0].Colors {
+		t.Fatalf("Invalid line sets %v", res.LineSets)
+	}
+	for i, p := range f.LineSets[0].Points {
+		if res.LineSets[0].Points[i] != p {
+			t.Errorf("Expected %v, got %v", p, res.LineSets[0].Points[i])
+		}
+	}
+}
+
+func TestRoundtripFractionalOffset(t *testing.T) {
+
+	f := rex.File{
+		CoordinateSystem: rex.CoordinateSystem{SRID: 31256, Authority: "EPSG", Offset: mgl32.Vec3{0.25, 100.125, 0}},
+		LineSets:         []rex.LineSet{{ID: 1, Colors: mgl32.Vec4{1, 1, 1, 1}, Points: []mgl32.Vec3{{1.5, 0, 2}, {-0.125, 3.75, 0.5}}}},
+	}
+	var buf bytes.Buffer
+	enc := NewEncoderWithOptions(&buf, EncoderOptions{Offset: [3]float64{0.25, 100.125, 0}, SRID: 31256})
+	if err := enc.Encode(f); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if !strings.Contains(buf.String(), "[[1.75,100.125,2],[0.125,103.875,0.5]]") {
+		t.Errorf("Invalid coordinates %s", buf.String())
+	}
+
+	res, err := NewDecoderWithOptions(&buf, DecoderOptions{Origin: [3]float64{0.25, 100.125, 0}}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if res.CoordinateSystem != f.CoordinateSystem {
+		t.Errorf("Expected %v, got %v", f.CoordinateSystem, res.CoordinateSystem)
+	}
+	for i, p := range f.LineSets[0].Points {
+		if res.LineSets[0].Points[i] != p {
+			t.Errorf("Expected %v, got %v", p, res.LineSets[0].Points[i])
+		}
+	}
+}
+
+func TestRoundtripInexactOffset(t *testing.T) {
+
+	// 500000.1 cannot be represented as float32, the exported values must not be biased
+	coords := "[[500000.1,5000000.2,10.3],[500012.34,4999990.05,0]]"
+	input := `{"type":"LineString","coordinates":` + coords + `}`
+	res, err := NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{Origin: [3]float64{500000.1, 5000000.2, 0}}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	out, err := ioutil.TempFile("", "roundtrip*.geojson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+	if err := WriteFile(out.Name(), *res); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	data, err := ioutil.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), coords) {
+		t.Errorf("Invalid coordinates %s", data)
+	}
+}
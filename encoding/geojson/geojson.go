@@ -0,0 +1,116 @@
+// Package geojson converts GeoJSON lines and points from and to REX line sets and point lists
+package geojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/internal/coord"
+)
+
+// WGS84 is the SRID of the GeoJSON default coordinate reference system (RFC 7946)
+const WGS84 = 4326
+
+// object is a GeoJSON object (feature collection, feature or geometry) which
+// is read, only the members of the given type are set
+type object struct {
+	Type        string                 `json:"type"`
+	CRS         *crs                   `json:"crs"`
+	Features    []object               `json:"features"`
+	Geometry    *object                `json:"geometry"`
+	Properties  map[string]interface{} `json:"properties"`
+	Geometries  []object               `json:"geometries"`
+	Coordinates json.RawMessage        `json:"coordinates"`
+}
+
+// crs is the named coordinate reference system of GeoJSON 2008, which is
+// still written by many tools for projected coordinates
+type crs struct {
+	Type       string `json:"type"`
+	Properties struct {
+		Name string `json:"name"`
+	} `json:"properties"`
+}
+
+var epsgName = regexp.MustCompile(`EPSG:+(\d+)$`)
+
+// srid returns the EPSG code of the CRS name, 0 if unknown
+func (c *crs) srid() uint32 {
+
+	if c == nil || c.Type != "name" {
+		return 0
+	}
+	if strings.HasSuffix(c.Properties.Name, "CRS84") {
+		return WGS84
+	}
+	m := epsgName.FindStringSubmatch(c.Properties.Name)
+	if m == nil {
+		return 0
+	}
+	srid, err := strconv.ParseUint(m[1], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(srid)
+}
+
+// newCRS creates the CRS member for the given EPSG code
+func newCRS(srid uint32) *crs {
+	c := &crs{Type: "name"}
+	c.Properties.Name = fmt.Sprintf("urn:ogc:def:crs:EPSG::%d", srid)
+	return c
+}
+
+// parseColor parses a hex color (#rrggbb or #rgb) as used by the simplestyle
+// properties stroke and marker-color
+func parseColor(s string) (mgl32.Vec3, bool) {
+
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return mgl32.Vec3{}, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return mgl32.Vec3{}, false
+	}
+	return mgl32.Vec3{float32(v>>16) / 255, float32(v>>8&0xff) / 255, float32(v&0xff) / 255}, true
+}
+
+// formatColor returns the color as #rrggbb
+func formatColor(c mgl32.Vec3) string {
+	var v [3]uint8
+	for i := range v {
+		v[i] = uint8(mgl32.Clamp(c[i], 0, 1)*255 + 0.5)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", v[0], v[1], v[2])
+}
+
+// position is a REX coordinate which is written incl. the offset
+type position struct {
+	v      mgl32.Vec3
+	offset [3]float64
+}
+
+// MarshalJSON writes the position incl. the offset with coord.Append
+func (p position) MarshalJSON() ([]byte, error) {
+
+	b := []byte{'['}
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if v := float64(p.v[i]) + p.offset[i]; math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("Invalid coordinate %v", v)
+		}
+		b = coord.Append(b, p.v[i], p.offset[i])
+	}
+	return append(b, ']'), nil
+}
@@ -0,0 +1,861 @@
+package gltf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// DecoderOptions control the glTF import
+type DecoderOptions struct {
+	// Bake applies the node transformations to the vertices, every mesh
+	// instance becomes a mesh in world coordinates. Otherwise the node
+	// hierarchy is flattened into scene nodes which reference the meshes in
+	// their local coordinates.
+	Bake bool
+	// Open opens the external buffers and images which are referenced by
+	// the glTF file. If nil, only embedded data (GLB or base64 data URIs) can
+	// be imported. The name is a slash separated path relative to the glTF
+	// file, URIs which leave its directory are rejected.
+	Open func(name string) (io.ReadCloser, error)
+}
+
+// Decoder reads a glTF 2.0 file (.gltf or .glb) and converts it into a REX
+// file. Triangle primitives become meshes, the base color textures become
+// images and the PBR materials are approximated by Phong materials.
+type Decoder struct {
+	r    io.Reader
+	opts DecoderOptions
+}
+
+// NewDecoder creates a new glTF decoder which flattens the node hierarchy
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// NewDecoderWithOptions creates a new glTF decoder with the given options
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	return &Decoder{r: r, opts: opts}
+}
+
+// ReadFile reads the given glTF file, external buffers and images are
+// resolved relative to the glTF file.
+func ReadFile(name string, bake bool) (*rex.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(name)
+	return NewDecoderWithOptions(f, DecoderOptions{
+		Bake: bake,
+		Open: func(ref string) (io.ReadCloser, error) {
+			return os.Open(filepath.Join(dir, filepath.FromSlash(ref)))
+		},
+	}).Decode()
+}
+
+// decoder holds the state while converting
+type decoder struct {
+	opts    DecoderOptions
+	doc     document
+	glb     []byte // BIN chunk of a GLB file
+	buffers [][]byte
+	file    *rex.File
+	id      uint64
+
+	images    map[int]uint64   // REX image ID by glTF image index
+	materials map[int]uint64   // REX material ID by glTF material index
+	meshes    map[int][]uint64 // REX mesh IDs of the primitives by glTF mesh index
+	templates map[uint64]rex.Mesh
+}
+
+// Decode reads the glTF file and returns the REX file
+func (dec *Decoder) Decode() (*rex.File, error) {
+
+	data, err := ioutil.ReadAll(dec.r)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decoder{
+		opts:      dec.opts,
+		file:      &rex.File{},
+		images:    make(map[int]uint64),
+		materials: make(map[int]uint64),
+		meshes:    make(map[int][]uint64),
+		templates: make(map[uint64]rex.Mesh),
+	}
+
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		data, d.glb, err = readGLB(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(data, &d.doc); err != nil {
+		return nil, fmt.Errorf("Invalid glTF document: %w", err)
+	}
+	if !strings.HasPrefix(d.doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("glTF version %s is not supported", d.doc.Asset.Version)
+	}
+
+	if err := d.loadBuffers(); err != nil {
+		return nil, err
+	}
+	if err := d.convertNodes(); err != nil {
+		return nil, err
+	}
+	return d.file, nil
+}
+
+// readGLB returns the JSON and BIN chunk of the binary glTF container
+func readGLB(data []byte) ([]byte, []byte, error) {
+
+	if len(data) < 20 {
+		return nil, nil, fmt.Errorf("GLB file is too short")
+	}
+	if v := binary.LittleEndian.Uint32(data[4:]); v != glbVersion {
+		return nil, nil, fmt.Errorf("GLB version %d is not supported", v)
+	}
+	length := binary.LittleEndian.Uint32(data[8:])
+	if length < 12 {
+		return nil, nil, fmt.Errorf("Invalid GLB length %d", length)
+	}
+	if int64(length) > int64(len(data)) {
+		return nil, nil, fmt.Errorf("GLB file is truncated")
+	}
+	data = data[12:length]
+
+	var jsonChunk, binChunk []byte
+	for len(data) >= 8 {
+		size := binary.LittleEndian.Uint32(data)
+		typ := binary.LittleEndian.Uint32(data[4:])
+		if int64(size) > int64(len(data)-8) {
+			return nil, nil, fmt.Errorf("GLB chunk is truncated")
+		}
+		chunk := data[8 : 8+size]
+		switch {
+		case typ == glbChunkJSON && jsonChunk == nil:
+			jsonChunk = chunk
+		case typ == glbChunkBIN && binChunk == nil:
+			binChunk = chunk
+		}
+		data = data[8+size:]
+	}
+	if jsonChunk == nil {
+		return nil, nil, fmt.Errorf("GLB file has no JSON chunk")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+// loadBuffers loads all buffers, the first buffer w/o URI is the BIN chunk of the GLB file
+func (d *decoder) loadBuffers() error {
+
+	d.buffers = make([][]byte, len(d.doc.Buffers))
+	for i, b := range d.doc.Buffers {
+		var data []byte
+		if b.URI == "" {
+			if i != 0 || d.glb == nil {
+				return fmt.Errorf("buffer %d has no data", i)
+			}
+			data = d.glb
+		} else {
+			var err error
+			if data, err = d.load(b.URI); err != nil {
+				return fmt.Errorf("buffer %d: %v", i, err)
+			}
+		}
+		if len(data) < b.ByteLength {
+			return fmt.Errorf("buffer %d is too short (%d < %d bytes)", i, len(data), b.ByteLength)
+		}
+		d.buffers[i] = data
+	}
+	return nil
+}
+
+// load returns the data of a data URI or an external file
+func (d *decoder) load(uri string) ([]byte, error) {
+
+	if strings.HasPrefix(uri, "data:") {
+		i := strings.Index(uri, ";base64,")
+		if i < 0 {
+			return nil, fmt.Errorf("only base64 data URIs are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[i+len(";base64,"):])
+	}
+
+	if d.opts.Open == nil {
+		return nil, fmt.Errorf("external file %s cannot be opened", uri)
+	}
+	name, err := localPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	f, err := d.opts.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// localPath returns the slash separated path of an external file relative to
+// the glTF file. Absolute paths, paths leaving the directory and URIs with a
+// scheme (e.g. file: or http:) are rejected.
+func localPath(uri string) (string, error) {
+
+	name, err := url.PathUnescape(uri)
+	if err != nil {
+		return "", err
+	}
+	name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if strings.Contains(name, ":") || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("external file %s is outside of the directory of the glTF file", uri)
+	}
+	return name, nil
+}
+
+func (d *decoder) nextID() uint64 {
+	d.id++
+	return d.id
+}
+
+// bufferView returns the data of the given buffer view
+func (d *decoder) bufferView(idx int) ([]byte, int, error) {
+
+	if idx < 0 || idx >= len(d.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("invalid buffer view %d", idx)
+	}
+	view := d.doc.BufferViews[idx]
+	if view.Buffer < 0 || view.Buffer >= len(d.buffers) {
+		return nil, 0, fmt.Errorf("invalid buffer %d", view.Buffer)
+	}
+	data := d.buffers[view.Buffer]
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset+view.ByteLength > len(data) {
+		return nil, 0, fmt.Errorf("buffer view %d exceeds buffer", idx)
+	}
+	return data[view.ByteOffset : view.ByteOffset+view.ByteLength], view.ByteStride, nil
+}
+
+// maxAccessorCount limits accessors w/o buffer view, their size is not limited by the data
+const maxAccessorCount = 1 << 24
+
+// fitsInto returns true if count elements with the given stride and element
+// size starting at offset fit into length bytes. The check is overflow safe.
+func fitsInto(offset, count, stride, size, length int) bool {
+
+	if offset < 0 || count < 0 || offset > length {
+		return false
+	}
+	if count == 0 {
+		return true
+	}
+	available := length - offset - size
+	if available < 0 {
+		return false
+	}
+	return stride == 0 || count-1 <= available/stride
+}
+
+var componentCount = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+}
+
+var componentSize = map[int]int{
+	typeByte:          1,
+	typeUnsignedByte:  1,
+	typeShort:         2,
+	typeUnsignedShort: 2,
+	typeUnsignedInt:   4,
+	typeFloat:         4,
+}
+
+// accessorReader decodes the elements of an accessor
+type accessorReader struct {
+	accessor
+	data   []byte // nil for accessors w/o buffer view, whose elements are zero
+	stride int
+	n      int // number of components
+	size   int // size of a component
+}
+
+// accessorReader validates the accessor and returns a reader for its elements
+func (d *decoder) accessorReader(idx int) (*accessorReader, error) {
+
+	if idx < 0 || idx >= len(d.doc.Accessors) {
+		return nil, fmt.Errorf("invalid accessor %d", idx)
+	}
+	a := d.doc.Accessors[idx]
+	n, ok := componentCount[a.Type]
+	size, ok2 := componentSize[a.ComponentType]
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("accessor %d: type %s/%d is not supported", idx, a.Type, a.ComponentType)
+	}
+	if a.Sparse != nil {
+		return nil, fmt.Errorf("accessor %d: sparse accessors are not supported", idx)
+	}
+	if a.Count < 0 || a.ByteOffset < 0 {
+		return nil, fmt.Errorf("accessor %d: invalid count or offset", idx)
+	}
+	r := &accessorReader{accessor: a, n: n, size: size}
+
+	// accessors w/o buffer view are initialized with zeros
+	if a.BufferView == nil {
+		if a.Count > maxAccessorCount {
+			return nil, fmt.Errorf("accessor %d: count %d is too large", idx, a.Count)
+		}
+		return r, nil
+	}
+
+	data, stride, err := d.bufferView(*a.BufferView)
+	if err != nil {
+		return nil, fmt.Errorf("accessor %d: %v", idx, err)
+	}
+	if stride == 0 {
+		stride = n * size
+	}
+	if !fitsInto(a.ByteOffset, a.Count, stride, n*size, len(data)) {
+		return nil, fmt.Errorf("accessor %d exceeds buffer view", idx)
+	}
+	r.data, r.stride = data, stride
+	return r, nil
+}
+
+// read decodes the element i into v. Normalized integer values are converted
+// into the range 0..1 (-1..1). Components beyond len(v) are ignored, missing
+// components are left unchanged.
+func (r *accessorReader) read(i int, v []float32) {
+
+	if r.data == nil {
+		return
+	}
+	b := r.data[r.ByteOffset+i*r.stride:]
+	for c := 0; c < r.n && c < len(v); c++ {
+		v[c] = component(b[c*r.size:], r.ComponentType, r.Normalized)
+	}
+}
+
+// readVec3s returns the elements of the accessor as vectors, additional
+// components (e.g. the alpha of colors) are ignored
+func (d *decoder) readVec3s(idx int) ([]mgl32.Vec3, error) {
+
+	r, err := d.accessorReader(idx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]mgl32.Vec3, r.Count)
+	for i := range res {
+		r.read(i, res[i][:])
+	}
+	return res, nil
+}
+
+// readVec2s returns the elements of the accessor as 2D vectors
+func (d *decoder) readVec2s(idx int) ([]mgl32.Vec2, error) {
+
+	r, err := d.accessorReader(idx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]mgl32.Vec2, r.Count)
+	for i := range res {
+		r.read(i, res[i][:])
+	}
+	return res, nil
+}
+
+func component(b []byte, componentType int, normalized bool) float32 {
+
+	var v, max float32
+	switch componentType {
+	case typeFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	case typeByte:
+		v, max = float32(int8(b[0])), 127
+	case typeUnsignedByte:
+		v, max = float32(b[0]), 255
+	case typeShort:
+		v, max = float32(int16(binary.LittleEndian.Uint16(b))), 32767
+	case typeUnsignedShort:
+		v, max = float32(binary.LittleEndian.Uint16(b)), 65535
+	case typeUnsignedInt:
+		return float32(binary.LittleEndian.Uint32(b))
+	}
+	if normalized {
+		return mgl32.Clamp(v/max, -1, 1)
+	}
+	return v
+}
+
+// readIndices returns the indices of the accessor
+func (d *decoder) readIndices(idx int) ([]uint32, error) {
+
+	if idx < 0 || idx >= len(d.doc.Accessors) {
+		return nil, fmt.Errorf("invalid accessor %d", idx)
+	}
+	a := d.doc.Accessors[idx]
+	size, ok := componentSize[a.ComponentType]
+	if a.Type != "SCALAR" || !ok || a.ComponentType == typeFloat || a.BufferView == nil {
+		return nil, fmt.Errorf("accessor %d: invalid indices", idx)
+	}
+	if a.Sparse != nil {
+		return nil, fmt.Errorf("accessor %d: sparse accessors are not supported", idx)
+	}
+	data, stride, err := d.bufferView(*a.BufferView)
+	if err != nil {
+		return nil, fmt.Errorf("accessor %d: %v", idx, err)
+	}
+	if stride == 0 {
+		stride = size
+	}
+	if !fitsInto(a.ByteOffset, a.Count, stride, size, len(data)) {
+		return nil, fmt.Errorf("accessor %d exceeds buffer view", idx)
+	}
+
+	indices := make([]uint32, a.Count)
+	for i := range indices {
+		b := data[a.ByteOffset+i*stride:]
+		switch size {
+		case 1:
+			indices[i] = uint32(b[0])
+		case 2:
+			indices[i] = uint32(binary.LittleEndian.Uint16(b))
+		case 4:
+			indices[i] = binary.LittleEndian.Uint32(b)
+		}
+	}
+	return indices, nil
+}
+
+// convertNodes traverses the node hierarchy of the default scene
+func (d *decoder) convertNodes() error {
+
+	var roots []int
+	switch {
+	case d.doc.Scene != nil && *d.doc.Scene >= 0 && *d.doc.Scene < len(d.doc.Scenes):
+		roots = d.doc.Scenes[*d.doc.Scene].Nodes
+	case len(d.doc.Scenes) > 0:
+		roots = d.doc.Scenes[0].Nodes
+	default:
+		// w/o scene all nodes which are not a child of another node are used
+		child := make(map[int]bool)
+		for _, n := range d.doc.Nodes {
+			for _, c := range n.Children {
+				child[c] = true
+			}
+		}
+		for i := range d.doc.Nodes {
+			if !child[i] {
+				roots = append(roots, i)
+			}
+		}
+	}
+
+	visited := make(map[int]bool)
+	for _, idx := range roots {
+		if err := d.convertNode(idx, mgl32.Ident4(), visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decoder) convertNode(idx int, parent mgl32.Mat4, visited map[int]bool) error {
+
+	if idx < 0 || idx >= len(d.doc.Nodes) {
+		return fmt.Errorf("invalid node %d", idx)
+	}
+	if visited[idx] {
+		return fmt.Errorf("node %d is referenced more than once", idx)
+	}
+	visited[idx] = true
+
+	n := d.doc.Nodes[idx]
+	world := parent.Mul4(localTransform(n))
+
+	if n.Mesh != nil {
+		ids, err := d.meshIDs(*n.Mesh)
+		if err != nil {
+			return err
+		}
+		if d.opts.Bake {
+			d.bakeMeshes(ids, world)
+		} else {
+			translation, rotation, scale := decompose(world)
+			for _, id := range ids {
+				sn := rex.NewSceneNode(d.nextID(), id, n.Name)
+				sn.Translation = translation
+				sn.Rotation = mgl32.Vec4{rotation.V[0], rotation.V[1], rotation.V[2], rotation.W}
+				sn.Scale = scale
+				d.file.SceneNodes = append(d.file.SceneNodes, sn)
+			}
+		}
+	}
+
+	for _, c := range n.Children {
+		if err := d.convertNode(c, world, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// localTransform returns the transformation matrix of the node
+func localTransform(n node) mgl32.Mat4 {
+
+	if len(n.Matrix) == 16 {
+		var m mgl32.Mat4
+		copy(m[:], n.Matrix) // both are column major
+		return m
+	}
+	m := mgl32.Ident4()
+	if n.Translation != nil {
+		m = mgl32.Translate3D(n.Translation[0], n.Translation[1], n.Translation[2])
+	}
+	if n.Rotation != nil {
+		q := mgl32.Quat{W: n.Rotation[3], V: mgl32.Vec3{n.Rotation[0], n.Rotation[1], n.Rotation[2]}}
+		m = m.Mul4(q.Normalize().Mat4())
+	}
+	if n.Scale != nil {
+		m = m.Mul4(mgl32.Scale3D(n.Scale[0], n.Scale[1], n.Scale[2]))
+	}
+	return m
+}
+
+// decompose splits the matrix into translation, rotation and scale. Shearing is lost.
+func decompose(m mgl32.Mat4) (mgl32.Vec3, mgl32.Quat, mgl32.Vec3) {
+
+	translation := m.Col(3).Vec3()
+	scale := mgl32.Vec3{m.Col(0).Vec3().Len(), m.Col(1).Vec3().Len(), m.Col(2).Vec3().Len()}
+	if m.Mat3().Det() < 0 {
+		scale[0] = -scale[0]
+	}
+
+	var r mgl32.Mat4
+	for i := 0; i < 3; i++ {
+		if scale[i] != 0 {
+			r.SetCol(i, m.Col(i).Mul(1/scale[i]))
+		}
+	}
+	r.SetCol(3, mgl32.Vec4{0, 0, 0, 1})
+	r.Set(3, 0, 0)
+	r.Set(3, 1, 0)
+	r.Set(3, 2, 0)
+	return translation, mgl32.Mat4ToQuat(r).Normalize(), scale
+}
+
+// bakeMeshes adds transformed copies of the meshes to the file
+func (d *decoder) bakeMeshes(ids []uint64, world mgl32.Mat4) {
+
+	normalMatrix := world.Mat3().Inv().Transpose()
+	for _, id := range ids {
+		src := d.templates[id]
+		m := src
+		m.ID = d.nextID()
+		m.Coords = make([]mgl32.Vec3, len(src.Coords))
+		for i, c := range src.Coords {
+			m.Coords[i] = mgl32.TransformCoordinate(c, world)
+		}
+		if src.Normals != nil {
+			m.Normals = make([]mgl32.Vec3, len(src.Normals))
+			for i, n := range src.Normals {
+				m.Normals[i] = normalMatrix.Mul3x1(n).Normalize()
+			}
+		}
+		// a mirroring transformation changes the winding order
+		if world.Mat3().Det() < 0 {
+			m.Triangles = make([]rex.Triangle, len(src.Triangles))
+			for i, t := range src.Triangles {
+				m.Triangles[i] = rex.Triangle{V0: t.V0, V1: t.V2, V2: t.V1}
+			}
+		}
+		d.file.Meshes = append(d.file.Meshes, m)
+	}
+}
+
+// meshIDs returns the REX meshes of the glTF mesh, the meshes are converted on first use.
+// If the meshes are baked, the converted meshes are only used as templates for the instances.
+func (d *decoder) meshIDs(idx int) ([]uint64, error) {
+
+	if ids, ok := d.meshes[idx]; ok {
+		return ids, nil
+	}
+	if idx < 0 || idx >= len(d.doc.Meshes) {
+		return nil, fmt.Errorf("invalid mesh %d", idx)
+	}
+
+	var ids []uint64
+	gm := d.doc.Meshes[idx]
+	for i, p := range gm.Primitives {
+		m, err := d.convertPrimitive(p)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d, primitive %d: %v", idx, i, err)
+		}
+		if m == nil {
+			continue
+		}
+		m.Name = gm.Name
+		if len(gm.Primitives) > 1 && m.Name != "" {
+			m.Name = fmt.Sprintf("%s_%d", gm.Name, i)
+		}
+		ids = append(ids, m.ID)
+		if d.opts.Bake {
+			d.templates[m.ID] = *m
+		} else {
+			d.file.Meshes = append(d.file.Meshes, *m)
+		}
+	}
+	d.meshes[idx] = ids
+	return ids, nil
+}
+
+// convertPrimitive converts a triangle primitive into a mesh, other primitives are ignored (nil)
+func (d *decoder) convertPrimitive(p primitive) (*rex.Mesh, error) {
+
+	mode := modeTriangles
+	if p.Mode != nil {
+		mode = *p.Mode
+	}
+	if mode != modeTriangles && mode != modeTriangleStrip && mode != modeTriangleFan {
+		return nil, nil
+	}
+	pos, ok := p.Attributes["POSITION"]
+	if !ok {
+		return nil, nil
+	}
+
+	m := &rex.Mesh{MaterialID: rex.NotSpecified}
+	var err error
+	if m.Coords, err = d.readVec3s(pos); err != nil {
+		return nil, err
+	}
+	if idx, ok := p.Attributes["NORMAL"]; ok {
+		if m.Normals, err = d.readVec3s(idx); err != nil {
+			return nil, err
+		}
+	}
+	if idx, ok := p.Attributes["TEXCOORD_0"]; ok {
+		if m.TexCoords, err = d.readVec2s(idx); err != nil {
+			return nil, err
+		}
+		for i := range m.TexCoords {
+			m.TexCoords[i][1] = 1 - m.TexCoords[i][1]
+		}
+	}
+	if idx, ok := p.Attributes["COLOR_0"]; ok {
+		if m.Colors, err = d.readVec3s(idx); err != nil {
+			return nil, err
+		}
+	}
+	for _, attr := range [][]mgl32.Vec3{m.Normals, m.Colors} {
+		if attr != nil && len(attr) != len(m.Coords) {
+			return nil, fmt.Errorf("attribute count does not match the vertex count")
+		}
+	}
+	if m.TexCoords != nil && len(m.TexCoords) != len(m.Coords) {
+		return nil, fmt.Errorf("attribute count does not match the vertex count")
+	}
+
+	var indices []uint32
+	if p.Indices != nil {
+		if indices, err = d.readIndices(*p.Indices); err != nil {
+			return nil, err
+		}
+	} else {
+		indices = make([]uint32, len(m.Coords))
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+	for _, i := range indices {
+		if int(i) >= len(m.Coords) {
+			return nil, fmt.Errorf("index %d out of range", i)
+		}
+	}
+	m.Triangles = triangles(indices, mode)
+	if len(m.Triangles) == 0 {
+		return nil, nil
+	}
+
+	if p.Material != nil {
+		if m.MaterialID, err = d.materialID(*p.Material); err != nil {
+			return nil, err
+		}
+	}
+	m.ID = d.nextID()
+	return m, nil
+}
+
+// triangles converts the indices of a triangle list, strip or fan into triangles
+func triangles(indices []uint32, mode int) []rex.Triangle {
+
+	var res []rex.Triangle
+	switch mode {
+	case modeTriangles:
+		for i := 0; i+2 < len(indices); i += 3 {
+			res = append(res, rex.Triangle{V0: indices[i], V1: indices[i+1], V2: indices[i+2]})
+		}
+	case modeTriangleStrip:
+		for i := 0; i+2 < len(indices); i++ {
+			// every second triangle is flipped in order to keep the winding order
+			if i%2 == 0 {
+				res = append(res, rex.Triangle{V0: indices[i], V1: indices[i+1], V2: indices[i+2]})
+			} else {
+				res = append(res, rex.Triangle{V0: indices[i+1], V1: indices[i], V2: indices[i+2]})
+			}
+		}
+	case modeTriangleFan:
+		for i := 1; i+1 < len(indices); i++ {
+			res = append(res, rex.Triangle{V0: indices[0], V1: indices[i], V2: indices[i+1]})
+		}
+	}
+	return res
+}
+
+// materialID converts the PBR material into a Phong material. The base color
+// is used as diffuse color, the specular color is derived from the metallic
+// factor (4% for dielectrics, the base color for metals) and the shininess from
+// the roughness.
+func (d *decoder) materialID(idx int) (uint64, error) {
+
+	if id, ok := d.materials[idx]; ok {
+		return id, nil
+	}
+	if idx < 0 || idx >= len(d.doc.Materials) {
+		return 0, fmt.Errorf("invalid material %d", idx)
+	}
+
+	// defaults of the glTF specification
+	baseColor := [4]float32{1, 1, 1, 1}
+	var metallic, roughness float32 = 1, 1
+	var baseColorTexture *textureInfo
+	if pbr := d.doc.Materials[idx].PbrMetallicRoughness; pbr != nil {
+		if pbr.BaseColorFactor != nil {
+			baseColor = *pbr.BaseColorFactor
+		}
+		if pbr.MetallicFactor != nil {
+			metallic = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			roughness = *pbr.RoughnessFactor
+		}
+		baseColorTexture = pbr.BaseColorTexture
+	}
+
+	kd := mgl32.Vec3{baseColor[0], baseColor[1], baseColor[2]}
+	mat := rex.NewMaterial(d.nextID())
+	mat.KdRgb = kd
+	mat.KsRgb = mgl32.Vec3{0.04, 0.04, 0.04}.Mul(1 - metallic).Add(kd.Mul(metallic))
+	mat.Ns = shininess(roughness)
+	mat.Alpha = baseColor[3]
+	if d.doc.Materials[idx].AlphaMode == "" || d.doc.Materials[idx].AlphaMode == "OPAQUE" {
+		mat.Alpha = 1
+	}
+
+	if baseColorTexture != nil {
+		id, err := d.textureID(baseColorTexture.Index)
+		if err != nil {
+			return 0, fmt.Errorf("material %d: %v", idx, err)
+		}
+		mat.KdTextureID = id
+	}
+
+	d.file.Materials = append(d.file.Materials, mat)
+	d.materials[idx] = mat.ID
+	return mat.ID, nil
+}
+
+// shininess converts the roughness into a Phong exponent, this is the inverse of roughness
+func shininess(roughness float32) float32 {
+	if roughness < 0.01 {
+		roughness = 0.01
+	}
+	return 2/(roughness*roughness) - 2
+}
+
+// textureID returns the image of the texture. Images which are neither JPEG
+// nor PNG are ignored (NotSpecified).
+func (d *decoder) textureID(idx int) (uint64, error) {
+
+	if idx < 0 || idx >= len(d.doc.Textures) {
+		return 0, fmt.Errorf("invalid texture %d", idx)
+	}
+	src := d.doc.Textures[idx].Source
+	if src == nil {
+		return rex.NotSpecified, nil
+	}
+	if id, ok := d.images[*src]; ok {
+		return id, nil
+	}
+	if *src < 0 || *src >= len(d.doc.Images) {
+		return 0, fmt.Errorf("invalid image %d", *src)
+	}
+
+	img := d.doc.Images[*src]
+	var data []byte
+	var err error
+	if img.BufferView != nil {
+		data, _, err = d.bufferView(*img.BufferView)
+	} else {
+		data, err = d.load(img.URI)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("image %d: %v", *src, err)
+	}
+
+	mimeType := img.MimeType
+	if mimeType == "" {
+		mimeType = mimeTypeOf(img.URI)
+	}
+	var compression uint32
+	switch mimeType {
+	case "image/jpeg":
+		compression = rex.Jpeg
+	case "image/png":
+		compression = rex.Png
+	default:
+		d.images[*src] = rex.NotSpecified
+		return rex.NotSpecified, nil
+	}
+
+	id := d.nextID()
+	d.file.Images = append(d.file.Images, rex.Image{ID: id, Compression: compression, Data: data})
+	d.images[*src] = id
+	return id, nil
+}
+
+// mimeTypeOf returns the mime type of a data URI or the file extension
+func mimeTypeOf(uri string) string {
+
+	if strings.HasPrefix(uri, "data:") {
+		if i := strings.IndexAny(uri, ";,"); i > 0 {
+			return uri[len("data:"):i]
+		}
+		return ""
+	}
+	switch strings.ToLower(path.Ext(uri)) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	}
+	return ""
+}
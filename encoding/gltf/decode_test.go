@@ -0,0 +1,226 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+func encodeGLB(t *testing.T, f rex.File) []byte {
+	var buf bytes.Buffer
+	if err := NewEncoderWithOptions(&buf, EncoderOptions{Binary: true}).Encode(f); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+
+	orig := testFile()
+	f, err := NewDecoder(bytes.NewReader(encodeGLB(t, orig))).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	if len(f.Meshes) != 2 || len(f.Materials) != 1 || len(f.Images) != 1 || len(f.SceneNodes) != 2 {
+		t.Fatalf("Expected 2 meshes, 1 material, 1 image and 2 scene nodes, got %d/%d/%d/%d",
+			len(f.Meshes), len(f.Materials), len(f.Images), len(f.SceneNodes))
+	}
+
+	m := f.Meshes[0]
+	if m.Name != "quad" || len(m.Triangles) != 2 {
+		t.Errorf("Invalid mesh %s with %d triangles", m.Name, len(m.Triangles))
+	}
+	for i := range m.Coords {
+		if m.Coords[i] != orig.Meshes[0].Coords[i] || m.Normals[i] != orig.Meshes[0].Normals[i] || m.TexCoords[i] != orig.Meshes[0].TexCoords[i] {
+			t.Fatalf("Vertex %d differs", i)
+		}
+	}
+	if f.Meshes[1].Normals != nil || f.Meshes[1].TexCoords != nil || f.Meshes[1].MaterialID != rex.NotSpecified {
+		t.Errorf("Triangle must not have normals, texture coordinates or material")
+	}
+
+	mat := f.Materials[0]
+	if m.MaterialID != mat.ID || mat.KdTextureID != f.Images[0].ID {
+		t.Errorf("Invalid material references")
+	}
+	if mat.Alpha != 0.5 || mat.KsRgb != (mgl32.Vec3{0.04, 0.04, 0.04}) || !mgl32.FloatEqualThreshold(mat.Ns, 64, 0.01) {
+		t.Errorf("Invalid material %+v", mat)
+	}
+	if f.Images[0].Compression != rex.Png || string(f.Images[0].Data) != "png" {
+		t.Errorf("Invalid image")
+	}
+
+	sn := f.SceneNodes[0]
+	if sn.GeometryID != m.ID || sn.Name != "quad" {
+		t.Errorf("Invalid scene node %+v", sn)
+	}
+	if !sn.Translation.ApproxEqual(mgl32.Vec3{1, 2, 3}) || !sn.Scale.ApproxEqual(mgl32.Vec3{2, 2, 2}) || !sn.Rotation.ApproxEqual(mgl32.Vec4{0, 0, 0, 1}) {
+		t.Errorf("Invalid transformation %v %v %v", sn.Translation, sn.Rotation, sn.Scale)
+	}
+	if f.SceneNodes[1].GeometryID != f.Meshes[1].ID {
+		t.Errorf("Invalid scene node %+v", f.SceneNodes[1])
+	}
+
+	if issues := rex.Validate(*f); len(issues) > 0 {
+		t.Errorf("Validation failed: %v", issues)
+	}
+}
+
+func TestDecodeBake(t *testing.T) {
+
+	f, err := NewDecoderWithOptions(bytes.NewReader(encodeGLB(t, testFile())), DecoderOptions{Bake: true}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.Meshes) != 2 || len(f.SceneNodes) != 0 {
+		t.Fatalf("Expected 2 meshes w/o scene nodes, got %d/%d", len(f.Meshes), len(f.SceneNodes))
+	}
+	if c := f.Meshes[0].Coords[2]; !c.ApproxEqual(mgl32.Vec3{3, 4, 3}) {
+		t.Errorf("Expected transformed vertex, got %v", c)
+	}
+	if n := f.Meshes[0].Normals[0]; !n.ApproxEqual(mgl32.Vec3{0, 0, 1}) {
+		t.Errorf("Expected normalized normal, got %v", n)
+	}
+}
+
+// hierarchyGltf builds a glTF with an embedded buffer: a triangle fan with
+// unsigned short indices which is referenced by a child of a rotated node
+func hierarchyGltf() string {
+
+	var bin bytes.Buffer
+	binary.Write(&bin, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0})
+	binary.Write(&bin, binary.LittleEndian, []uint16{0, 1, 2, 3})
+
+	return fmt.Sprintf(`{
+  "asset": {"version": "2.0"},
+  "scene": 0,
+  "scenes": [{"nodes": [0]}],
+  "nodes": [
+    {"name": "parent", "translation": [10, 0, 0], "rotation": [0, 0, 0.7071068, 0.7071068], "children": [1]},
+    {"name": "child", "mesh": 0, "translation": [1, 0, 0]}
+  ],
+  "meshes": [{"name": "fan", "primitives": [{"attributes": {"POSITION": 0}, "indices": 1, "mode": 6, "material": 0}]}],
+  "materials": [{"pbrMetallicRoughness": {"baseColorFactor": [1, 0, 0, 0.5]}}],
+  "accessors": [
+    {"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3"},
+    {"bufferView": 0, "byteOffset": 48, "componentType": 5123, "count": 4, "type": "SCALAR"}
+  ],
+  "bufferViews": [{"buffer": 0, "byteLength": %d}],
+  "buffers": [{"uri": "data:application/octet-stream;base64,%s", "byteLength": %d}]
+}`, bin.Len(), base64.StdEncoding.EncodeToString(bin.Bytes()), bin.Len())
+}
+
+func TestDecodeHierarchy(t *testing.T) {
+
+	f, err := NewDecoder(strings.NewReader(hierarchyGltf())).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.Meshes) != 1 || len(f.SceneNodes) != 1 {
+		t.Fatalf("Expected 1 mesh and 1 scene node, got %d/%d", len(f.Meshes), len(f.SceneNodes))
+	}
+	if len(f.Meshes[0].Triangles) != 2 || f.Meshes[0].Triangles[1] != (rex.Triangle{V0: 0, V1: 2, V2: 3}) {
+		t.Errorf("Invalid triangle fan %v", f.Meshes[0].Triangles)
+	}
+
+	// the child is rotated by 90 degree around z with the parent
+	sn := f.SceneNodes[0]
+	if sn.Name != "child" || !sn.Translation.ApproxEqualThreshold(mgl32.Vec3{10, 1, 0}, 1e-5) {
+		t.Errorf("Invalid scene node %s at %v", sn.Name, sn.Translation)
+	}
+	if !sn.Rotation.ApproxEqualThreshold(mgl32.Vec4{0, 0, 0.7071068, 0.7071068}, 1e-5) {
+		t.Errorf("Invalid rotation %v", sn.Rotation)
+	}
+
+	// default metallic factor is 1, the specular color is the base color
+	mat := f.Materials[0]
+	if mat.KdRgb != (mgl32.Vec3{1, 0, 0}) || mat.KsRgb != (mgl32.Vec3{1, 0, 0}) || mat.Alpha != 1 {
+		t.Errorf("Invalid material %+v", mat)
+	}
+
+	// baked
+	f, err = NewDecoderWithOptions(strings.NewReader(hierarchyGltf()), DecoderOptions{Bake: true}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if c := f.Meshes[0].Coords[1]; !c.ApproxEqualThreshold(mgl32.Vec3{10, 2, 0}, 1e-5) {
+		t.Errorf("Expected transformed vertex, got %v", c)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+
+	valid := hierarchyGltf()
+	tests := []string{
+		`{"asset": {"version": "1.0"}}`,
+		strings.Replace(valid, `"indices": 1`, `"indices": 5`, 1),
+		strings.Replace(valid, `"count": 4, "type": "VEC3"`, `"count": 40, "type": "VEC3"`, 1),
+		strings.Replace(valid, `"children": [1]`, `"children": [0]`, 1),
+		strings.Replace(valid, `data:application/octet-stream;base64,`, `external.bin#`, 1),
+		strings.Replace(valid, `"count": 4, "type": "SCALAR"`, `"count": -1, "type": "SCALAR"`, 1),
+		strings.Replace(valid, `"count": 4, "type": "VEC3"`, `"count": -1, "type": "VEC3"`, 1),
+		strings.Replace(valid, `"byteOffset": 48`, `"byteOffset": -8`, 1),
+		strings.Replace(valid, `"count": 4, "type": "VEC3"`, `"count": 9223372036854775807, "type": "VEC3"`, 1),
+		strings.Replace(valid, `{"bufferView": 0, "componentType": 5126, "count": 4`, `{"componentType": 5126, "count": 1000000000000`, 1),
+		strings.Replace(valid, `"count": 4, "type": "VEC3"`, `"count": 4, "type": "VEC3", "sparse": {"count": 1}`, 1),
+	}
+	for i, data := range tests {
+		if _, err := NewDecoder(strings.NewReader(data)).Decode(); err == nil {
+			t.Errorf("Expected error for test %d", i)
+		}
+	}
+
+	// truncated GLB
+	glb := encodeGLB(t, testFile())
+	if _, err := NewDecoder(bytes.NewReader(glb[:len(glb)-10])).Decode(); err == nil {
+		t.Errorf("Expected error for truncated GLB")
+	}
+
+	// GLB length below the header size
+	invalid := append([]byte{}, glb...)
+	binary.LittleEndian.PutUint32(invalid[8:], 4)
+	if _, err := NewDecoder(bytes.NewReader(invalid)).Decode(); err == nil {
+		t.Errorf("Expected error for invalid GLB length")
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+
+	valid := map[string]string{
+		"buffer.bin":            "buffer.bin",
+		"textures/my%20tex.png": "textures/my tex.png",
+		"a/../b.bin":            "b.bin",
+		"textures\\tex.png":     "textures/tex.png",
+	}
+	for uri, expected := range valid {
+		if name, err := localPath(uri); err != nil || name != expected {
+			t.Errorf("Expected %s for %s, got %s (%v)", expected, uri, name, err)
+		}
+	}
+
+	for _, uri := range []string{"../secret.bin", "a/../../secret.bin", "/etc/passwd", "%2Fetc%2Fpasswd",
+		"..\\secret.bin", "file:///etc/passwd", "C:\\secret.bin", "http://example.com/buffer.bin"} {
+		if _, err := localPath(uri); err == nil {
+			t.Errorf("Expected error for %s", uri)
+		}
+	}
+
+	// the decoder must not open such files
+	opened := false
+	open := func(name string) (io.ReadCloser, error) {
+		opened = true
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	data := strings.Replace(hierarchyGltf(), `data:application/octet-stream;base64,`, `../external.bin#`, 1)
+	if _, err := NewDecoderWithOptions(strings.NewReader(data), DecoderOptions{Open: open}).Decode(); err == nil || opened {
+		t.Errorf("Expected error w/o opening the file")
+	}
+}
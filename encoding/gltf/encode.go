@@ -0,0 +1,364 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// EncoderOptions control the glTF export
+type EncoderOptions struct {
+	// Binary writes a single GLB file instead of a JSON glTF file
+	Binary bool
+	// BufferName is the name of the external binary buffer (.bin) of a JSON
+	// glTF file which is created with Create. If empty, the buffer is embedded
+	// as base64 data URI.
+	BufferName string
+	// Create creates the external binary buffer
+	Create func(name string) (io.WriteCloser, error)
+}
+
+// Encoder writes a REX file as glTF 2.0 file. Meshes become glTF meshes,
+// the Phong materials are approximated by PBR materials and JPEG/PNG images are
+// embedded into the binary buffer. Scene nodes become glTF nodes, meshes which
+// are not referenced by any scene node are added as nodes w/o transformation.
+//
+// The texture coordinates are flipped vertically, because the origin of a
+// glTF texture is the upper left corner.
+type Encoder struct {
+	w    io.Writer
+	opts EncoderOptions
+}
+
+// NewEncoder creates a new glTF encoder which writes a JSON glTF file with embedded buffer
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// NewEncoderWithOptions creates a new glTF encoder with the given options
+func NewEncoderWithOptions(w io.Writer, opts EncoderOptions) *Encoder {
+	return &Encoder{w: w, opts: opts}
+}
+
+// WriteFile writes the REX file as glTF file. Files with the extension .glb
+// are written as binary glTF, otherwise the binary buffer is written as .bin
+// file next to the glTF file.
+func WriteFile(name string, f rex.File) error {
+
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	opts := EncoderOptions{Binary: strings.EqualFold(filepath.Ext(name), ".glb")}
+	if !opts.Binary {
+		dir := filepath.Dir(name)
+		opts.BufferName = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)) + ".bin"
+		opts.Create = func(ref string) (io.WriteCloser, error) {
+			return os.Create(filepath.Join(dir, ref))
+		}
+	}
+	if err := NewEncoderWithOptions(out, opts).Encode(f); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// encoder holds the state while building the glTF document
+type encoder struct {
+	doc       document
+	bin       bytes.Buffer
+	meshes    map[uint64]int // glTF mesh index by REX mesh ID
+	materials map[uint64]int // glTF material index by REX material ID
+	textures  map[uint64]int // glTF texture index by REX image ID
+}
+
+// Encode writes the REX file as glTF
+func (enc *Encoder) Encode(f rex.File) error {
+
+	e := &encoder{
+		doc: document{
+			Asset: asset{Version: "2.0", Generator: "gorex"},
+		},
+		meshes:    make(map[uint64]int),
+		materials: make(map[uint64]int),
+		textures:  make(map[uint64]int),
+	}
+
+	for _, img := range f.Images {
+		e.addImage(img)
+	}
+	for _, m := range f.Materials {
+		e.addMaterial(m)
+	}
+	for _, m := range f.Meshes {
+		e.addMesh(m)
+	}
+	e.addNodes(f)
+
+	// buffers must be aligned to 4 bytes
+	e.align()
+	if e.bin.Len() > 0 {
+		e.doc.Buffers = []buffer{{ByteLength: e.bin.Len()}}
+	}
+
+	if enc.opts.Binary {
+		return e.writeGLB(enc.w)
+	}
+
+	if e.bin.Len() > 0 {
+		if enc.opts.BufferName != "" && enc.opts.Create != nil {
+			if err := writeBuffer(enc.opts.Create, enc.opts.BufferName, e.bin.Bytes()); err != nil {
+				return err
+			}
+			e.doc.Buffers[0].URI = enc.opts.BufferName
+		} else {
+			e.doc.Buffers[0].URI = "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(e.bin.Bytes())
+		}
+	}
+	data, err := json.MarshalIndent(e.doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = enc.w.Write(data)
+	return err
+}
+
+// writeGLB writes the binary glTF container with a JSON and a BIN chunk
+func (e *encoder) writeGLB(w io.Writer) error {
+
+	data, err := json.Marshal(e.doc)
+	if err != nil {
+		return err
+	}
+	// the JSON chunk is padded with spaces
+	for len(data)%4 != 0 {
+		data = append(data, ' ')
+	}
+
+	length := 12 + 8 + len(data)
+	if e.bin.Len() > 0 {
+		length += 8 + e.bin.Len()
+	}
+	var chunks = []interface{}{
+		uint32(glbMagic),
+		uint32(glbVersion),
+		uint32(length),
+		uint32(len(data)),
+		uint32(glbChunkJSON),
+		data,
+	}
+	if e.bin.Len() > 0 {
+		chunks = append(chunks, uint32(e.bin.Len()), uint32(glbChunkBIN), e.bin.Bytes())
+	}
+	for _, v := range chunks {
+		if err := binary.Write(w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// align pads the binary buffer to a multiple of 4 bytes
+func (e *encoder) align() {
+	for e.bin.Len()%4 != 0 {
+		e.bin.WriteByte(0)
+	}
+}
+
+// addBufferView appends the data to the binary buffer and returns the index of the view
+func (e *encoder) addBufferView(data interface{}, target int) int {
+
+	e.align()
+	offset := e.bin.Len()
+	binary.Write(&e.bin, binary.LittleEndian, data)
+
+	view := bufferView{ByteOffset: offset, ByteLength: e.bin.Len() - offset}
+	if target != 0 {
+		view.Target = intPtr(target)
+	}
+	e.doc.BufferViews = append(e.doc.BufferViews, view)
+	return len(e.doc.BufferViews) - 1
+}
+
+// addAccessor appends the data as accessor and returns its index
+func (e *encoder) addAccessor(data interface{}, target, componentType, count int, typ string) int {
+
+	e.doc.Accessors = append(e.doc.Accessors, accessor{
+		BufferView:    intPtr(e.addBufferView(data, target)),
+		ComponentType: componentType,
+		Count:         count,
+		Type:          typ,
+	})
+	return len(e.doc.Accessors) - 1
+}
+
+// addImage embeds JPEG and PNG images, other images are not supported by glTF
+func (e *encoder) addImage(img rex.Image) {
+
+	var mimeType string
+	switch img.Compression {
+	case rex.Jpeg:
+		mimeType = "image/jpeg"
+	case rex.Png:
+		mimeType = "image/png"
+	default:
+		return
+	}
+
+	e.doc.Images = append(e.doc.Images, image{
+		BufferView: intPtr(e.addBufferView(img.Data, 0)),
+		MimeType:   mimeType,
+	})
+	if len(e.doc.Samplers) == 0 {
+		e.doc.Samplers = []sampler{{}}
+	}
+	e.doc.Textures = append(e.doc.Textures, texture{
+		Sampler: intPtr(0),
+		Source:  intPtr(len(e.doc.Images) - 1),
+	})
+	e.textures[img.ID] = len(e.doc.Textures) - 1
+}
+
+// addMaterial approximates the Phong material by a PBR material. The diffuse
+// color is used as base color, the shininess is converted to roughness and the
+// material is not metallic. The ambient and specular colors are not used.
+func (e *encoder) addMaterial(m rex.Material) {
+
+	pbr := &pbrMetallicRoughness{
+		BaseColorFactor: &[4]float32{m.KdRgb[0], m.KdRgb[1], m.KdRgb[2], m.Alpha},
+		MetallicFactor:  float32Ptr(0),
+		RoughnessFactor: float32Ptr(roughness(m.Ns)),
+	}
+	// the texture is multiplied with the base color
+	if tex, ok := e.textures[m.KdTextureID]; ok {
+		pbr.BaseColorFactor = &[4]float32{1, 1, 1, m.Alpha}
+		pbr.BaseColorTexture = &textureInfo{Index: tex}
+	}
+
+	mat := material{PbrMetallicRoughness: pbr}
+	if m.Alpha < 1 {
+		mat.AlphaMode = "BLEND"
+	}
+	e.doc.Materials = append(e.doc.Materials, mat)
+	e.materials[m.ID] = len(e.doc.Materials) - 1
+}
+
+// roughness converts the Phong exponent to a roughness value (Blinn-Phong to GGX approximation)
+func roughness(ns float32) float32 {
+	if ns < 0 {
+		ns = 0
+	}
+	return float32(math.Sqrt(2 / (float64(ns) + 2)))
+}
+
+func (e *encoder) addMesh(m rex.Mesh) {
+
+	if len(m.Coords) == 0 || len(m.Triangles) == 0 {
+		return
+	}
+
+	min := m.Coords[0]
+	max := m.Coords[0]
+	for _, c := range m.Coords {
+		for i := 0; i < 3; i++ {
+			if c[i] < min[i] {
+				min[i] = c[i]
+			}
+			if c[i] > max[i] {
+				max[i] = c[i]
+			}
+		}
+	}
+
+	p := primitive{Attributes: make(map[string]int)}
+	p.Attributes["POSITION"] = e.addAccessor(m.Coords, targetArrayBuffer, typeFloat, len(m.Coords), "VEC3")
+	e.doc.Accessors[p.Attributes["POSITION"]].Min = min[:]
+	e.doc.Accessors[p.Attributes["POSITION"]].Max = max[:]
+
+	if len(m.Normals) == len(m.Coords) {
+		p.Attributes["NORMAL"] = e.addAccessor(m.Normals, targetArrayBuffer, typeFloat, len(m.Normals), "VEC3")
+	}
+	if len(m.TexCoords) == len(m.Coords) {
+		uv := make([]mgl32.Vec2, len(m.TexCoords))
+		for i, t := range m.TexCoords {
+			uv[i] = mgl32.Vec2{t[0], 1 - t[1]}
+		}
+		p.Attributes["TEXCOORD_0"] = e.addAccessor(uv, targetArrayBuffer, typeFloat, len(uv), "VEC2")
+	}
+	if len(m.Colors) == len(m.Coords) {
+		p.Attributes["COLOR_0"] = e.addAccessor(m.Colors, targetArrayBuffer, typeFloat, len(m.Colors), "VEC3")
+	}
+	p.Indices = intPtr(e.addAccessor(m.Triangles, targetElementArrayBuffer, typeUnsignedInt, len(m.Triangles)*3, "SCALAR"))
+	if mat, ok := e.materials[m.MaterialID]; ok {
+		p.Material = intPtr(mat)
+	}
+
+	e.doc.Meshes = append(e.doc.Meshes, mesh{Name: m.Name, Primitives: []primitive{p}})
+	e.meshes[m.ID] = len(e.doc.Meshes) - 1
+}
+
+// addNodes adds a node for every scene node and every mesh which is not
+// referenced by a scene node. All nodes are part of the default scene.
+func (e *encoder) addNodes(f rex.File) {
+
+	referenced := make(map[uint64]bool)
+	for _, sn := range f.SceneNodes {
+		n := node{Name: strings.TrimRight(sn.Name, "\x00")}
+		if idx, ok := e.meshes[sn.GeometryID]; ok {
+			n.Mesh = intPtr(idx)
+			referenced[sn.GeometryID] = true
+		}
+		if sn.Translation != (mgl32.Vec3{}) {
+			n.Translation = &[3]float32{sn.Translation[0], sn.Translation[1], sn.Translation[2]}
+		}
+		// glTF requires a unit quaternion, invalid rotations are ignored
+		if q := sn.Rotation; q != (mgl32.Vec4{0, 0, 0, 1}) && q.Len() > 0 {
+			q = q.Normalize()
+			n.Rotation = &[4]float32{q[0], q[1], q[2], q[3]}
+		}
+		if sn.Scale != (mgl32.Vec3{1, 1, 1}) && sn.Scale != (mgl32.Vec3{}) {
+			n.Scale = &[3]float32{sn.Scale[0], sn.Scale[1], sn.Scale[2]}
+		}
+		e.doc.Nodes = append(e.doc.Nodes, n)
+	}
+
+	for _, m := range f.Meshes {
+		if idx, ok := e.meshes[m.ID]; ok && !referenced[m.ID] {
+			e.doc.Nodes = append(e.doc.Nodes, node{Name: m.Name, Mesh: intPtr(idx)})
+		}
+	}
+
+	if len(e.doc.Nodes) == 0 {
+		return
+	}
+	s := scene{Nodes: make([]int, len(e.doc.Nodes))}
+	for i := range s.Nodes {
+		s.Nodes[i] = i
+	}
+	e.doc.Scenes = []scene{s}
+	e.doc.Scene = intPtr(0)
+}
+
+func writeBuffer(create func(string) (io.WriteCloser, error), name string, data []byte) error {
+
+	w, err := create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
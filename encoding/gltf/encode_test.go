@@ -0,0 +1,192 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// testFile returns a textured quad which is placed by a scene node and an untextured triangle
+func testFile() rex.File {
+
+	mat := rex.NewMaterial(2)
+	mat.KdTextureID = 3
+	mat.Alpha = 0.5
+
+	sn := rex.NewSceneNode(4, 1, "quad")
+	sn.Translation = mgl32.Vec3{1, 2, 3}
+	sn.Scale = mgl32.Vec3{2, 2, 2}
+
+	return rex.File{
+		Meshes: []rex.Mesh{
+			{
+				ID:         1,
+				Name:       "quad",
+				Coords:     []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+				Normals:    []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+				TexCoords:  []mgl32.Vec2{{0, 0}, {1, 0}, {1, 1}, {0, 1}},
+				Triangles:  []rex.Triangle{{V0: 0, V1: 1, V2: 2}, {V0: 0, V1: 2, V2: 3}},
+				MaterialID: 2,
+			},
+			{
+				ID:         5,
+				Name:       "triangle",
+				Coords:     []mgl32.Vec3{{0, 0, 0}, {-1, 0, 0}, {0, -1, 0}},
+				Triangles:  []rex.Triangle{{V0: 0, V1: 2, V2: 1}},
+				MaterialID: rex.NotSpecified,
+			},
+		},
+		Materials:  []rex.Material{mat},
+		Images:     []rex.Image{{ID: 3, Compression: rex.Png, Data: []byte("png")}},
+		SceneNodes: []rex.SceneNode{sn},
+	}
+}
+
+func TestEncodeGLB(t *testing.T) {
+
+	var buf bytes.Buffer
+	if err := NewEncoderWithOptions(&buf, EncoderOptions{Binary: true}).Encode(testFile()); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	var hdr struct {
+		Magic, Version, Length uint32
+		JSONLength, JSONType   uint32
+	}
+	data := buf.Bytes()
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &hdr); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if hdr.Magic != glbMagic || hdr.Version != 2 || int(hdr.Length) != len(data) || hdr.JSONType != glbChunkJSON {
+		t.Fatalf("Invalid GLB header %+v (%d bytes)", hdr, len(data))
+	}
+	if hdr.JSONLength%4 != 0 {
+		t.Errorf("JSON chunk is not aligned: %d", hdr.JSONLength)
+	}
+
+	var doc document
+	if err := json.Unmarshal(data[20:20+hdr.JSONLength], &doc); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	bin := data[20+hdr.JSONLength:]
+	binLength := binary.LittleEndian.Uint32(bin)
+	if binary.LittleEndian.Uint32(bin[4:]) != glbChunkBIN || int(binLength) != len(bin)-8 {
+		t.Fatalf("Invalid BIN chunk")
+	}
+	if len(doc.Buffers) != 1 || doc.Buffers[0].URI != "" || doc.Buffers[0].ByteLength != int(binLength) {
+		t.Errorf("Invalid buffer %+v", doc.Buffers)
+	}
+
+	if len(doc.Meshes) != 2 || len(doc.Materials) != 1 || len(doc.Images) != 1 || len(doc.Textures) != 1 {
+		t.Fatalf("Expected 2 meshes, 1 material, image and texture, got %d/%d/%d/%d",
+			len(doc.Meshes), len(doc.Materials), len(doc.Images), len(doc.Textures))
+	}
+
+	// image is embedded
+	view := doc.BufferViews[*doc.Images[0].BufferView]
+	if doc.Images[0].MimeType != "image/png" || string(bin[8+view.ByteOffset:8+view.ByteOffset+view.ByteLength]) != "png" {
+		t.Errorf("Image not embedded")
+	}
+
+	// material
+	pbr := doc.Materials[0].PbrMetallicRoughness
+	if pbr.BaseColorTexture == nil || *pbr.BaseColorFactor != [4]float32{1, 1, 1, 0.5} || doc.Materials[0].AlphaMode != "BLEND" {
+		t.Errorf("Invalid material %+v", pbr)
+	}
+
+	// mesh with all attributes, vertically flipped texture coordinates
+	p := doc.Meshes[0].Primitives[0]
+	for _, attr := range []string{"POSITION", "NORMAL", "TEXCOORD_0"} {
+		if _, ok := p.Attributes[attr]; !ok {
+			t.Errorf("Attribute %s is missing", attr)
+		}
+	}
+	uv := doc.Accessors[p.Attributes["TEXCOORD_0"]]
+	offset := 8 + doc.BufferViews[*uv.BufferView].ByteOffset
+	var first mgl32.Vec2
+	binary.Read(bytes.NewReader(bin[offset:]), binary.LittleEndian, &first)
+	if first != (mgl32.Vec2{0, 1}) {
+		t.Errorf("Expected flipped texture coordinate, got %v", first)
+	}
+	pos := doc.Accessors[p.Attributes["POSITION"]]
+	if pos.Count != 4 || len(pos.Min) != 3 || pos.Max[0] != 1 {
+		t.Errorf("Invalid position accessor %+v", pos)
+	}
+	if doc.Accessors[*p.Indices].Count != 6 || *p.Material != 0 {
+		t.Errorf("Invalid indices or material")
+	}
+	if _, ok := doc.Meshes[1].Primitives[0].Attributes["NORMAL"]; ok || doc.Meshes[1].Primitives[0].Material != nil {
+		t.Errorf("Triangle must not have normals or material")
+	}
+
+	// scene node and the unreferenced triangle
+	if len(doc.Nodes) != 2 || len(doc.Scenes) != 1 || len(doc.Scenes[0].Nodes) != 2 {
+		t.Fatalf("Expected 2 nodes in the scene, got %d", len(doc.Nodes))
+	}
+	n := doc.Nodes[0]
+	if *n.Mesh != 0 || *n.Translation != [3]float32{1, 2, 3} || *n.Scale != [3]float32{2, 2, 2} || n.Rotation != nil {
+		t.Errorf("Invalid node %+v", n)
+	}
+	if *doc.Nodes[1].Mesh != 1 || doc.Nodes[1].Translation != nil {
+		t.Errorf("Invalid node %+v", doc.Nodes[1])
+	}
+}
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func TestEncodeJSON(t *testing.T) {
+
+	// embedded buffer
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(testFile()); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	var doc document
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(doc.Buffers) != 1 || !strings.HasPrefix(doc.Buffers[0].URI, "data:application/octet-stream;base64,") {
+		t.Errorf("Expected embedded buffer")
+	}
+
+	// external buffer
+	bin := &bytes.Buffer{}
+	buf.Reset()
+	err := NewEncoderWithOptions(&buf, EncoderOptions{
+		BufferName: "test.bin",
+		Create: func(name string) (io.WriteCloser, error) {
+			return nopCloser{bin}, nil
+		},
+	}).Encode(testFile())
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	doc = document{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if doc.Buffers[0].URI != "test.bin" || doc.Buffers[0].ByteLength != bin.Len() {
+		t.Errorf("Invalid external buffer %+v (%d bytes)", doc.Buffers[0], bin.Len())
+	}
+}
+
+func TestRoughness(t *testing.T) {
+	if r := roughness(0); r != 1 {
+		t.Errorf("Expected roughness 1, got %f", r)
+	}
+	if r := roughness(1000); r > 0.1 {
+		t.Errorf("Expected low roughness, got %f", r)
+	}
+}
@@ -0,0 +1,159 @@
+// Package gltf converts glTF 2.0 files (.gltf and .glb) from and to REX files
+package gltf
+
+// The types of this file describe the subset of the glTF 2.0 JSON schema
+// which is required for the conversion, see https://www.khronos.org/registry/glTF/specs/2.0/glTF-2.0.html
+
+const (
+	glbMagic     = 0x46546C67 // glTF
+	glbVersion   = 2
+	glbChunkJSON = 0x4E4F534A // JSON
+	glbChunkBIN  = 0x004E4942 // BIN
+)
+
+// accessor component types
+const (
+	typeByte          = 5120
+	typeUnsignedByte  = 5121
+	typeShort         = 5122
+	typeUnsignedShort = 5123
+	typeUnsignedInt   = 5125
+	typeFloat         = 5126
+)
+
+// buffer view targets
+const (
+	targetArrayBuffer        = 34962
+	targetElementArrayBuffer = 34963
+)
+
+// primitive modes
+const (
+	modeTriangles     = 4
+	modeTriangleStrip = 5
+	modeTriangleFan   = 6
+)
+
+type document struct {
+	Asset       asset        `json:"asset"`
+	Scene       *int         `json:"scene,omitempty"`
+	Scenes      []scene      `json:"scenes,omitempty"`
+	Nodes       []node       `json:"nodes,omitempty"`
+	Meshes      []mesh       `json:"meshes,omitempty"`
+	Materials   []material   `json:"materials,omitempty"`
+	Textures    []texture    `json:"textures,omitempty"`
+	Images      []image      `json:"images,omitempty"`
+	Samplers    []sampler    `json:"samplers,omitempty"`
+	Accessors   []accessor   `json:"accessors,omitempty"`
+	BufferViews []bufferView `json:"bufferViews,omitempty"`
+	Buffers     []buffer     `json:"buffers,omitempty"`
+}
+
+type asset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+}
+
+type scene struct {
+	Name  string `json:"name,omitempty"`
+	Nodes []int  `json:"nodes,omitempty"`
+}
+
+type node struct {
+	Name        string      `json:"name,omitempty"`
+	Mesh        *int        `json:"mesh,omitempty"`
+	Children    []int       `json:"children,omitempty"`
+	Matrix      []float32   `json:"matrix,omitempty"`
+	Translation *[3]float32 `json:"translation,omitempty"`
+	Rotation    *[4]float32 `json:"rotation,omitempty"`
+	Scale       *[3]float32 `json:"scale,omitempty"`
+}
+
+type mesh struct {
+	Name       string      `json:"name,omitempty"`
+	Primitives []primitive `json:"primitives"`
+}
+
+type primitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices,omitempty"`
+	Material   *int           `json:"material,omitempty"`
+	Mode       *int           `json:"mode,omitempty"`
+}
+
+type material struct {
+	Name                 string                `json:"name,omitempty"`
+	PbrMetallicRoughness *pbrMetallicRoughness `json:"pbrMetallicRoughness,omitempty"`
+	AlphaMode            string                `json:"alphaMode,omitempty"`
+	DoubleSided          bool                  `json:"doubleSided,omitempty"`
+}
+
+type pbrMetallicRoughness struct {
+	BaseColorFactor  *[4]float32  `json:"baseColorFactor,omitempty"`
+	BaseColorTexture *textureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   *float32     `json:"metallicFactor,omitempty"`
+	RoughnessFactor  *float32     `json:"roughnessFactor,omitempty"`
+}
+
+type textureInfo struct {
+	Index    int `json:"index"`
+	TexCoord int `json:"texCoord,omitempty"`
+}
+
+type texture struct {
+	Sampler *int `json:"sampler,omitempty"`
+	Source  *int `json:"source,omitempty"`
+}
+
+type image struct {
+	Name       string `json:"name,omitempty"`
+	URI        string `json:"uri,omitempty"`
+	MimeType   string `json:"mimeType,omitempty"`
+	BufferView *int   `json:"bufferView,omitempty"`
+}
+
+type sampler struct {
+	MagFilter int `json:"magFilter,omitempty"`
+	MinFilter int `json:"minFilter,omitempty"`
+	WrapS     int `json:"wrapS,omitempty"`
+	WrapT     int `json:"wrapT,omitempty"`
+}
+
+type accessor struct {
+	BufferView    *int      `json:"bufferView,omitempty"`
+	ByteOffset    int       `json:"byteOffset,omitempty"`
+	ComponentType int       `json:"componentType"`
+	Normalized    bool      `json:"normalized,omitempty"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+	Sparse        *sparse   `json:"sparse,omitempty"`
+}
+
+// sparse accessors are not supported, the member is only decoded in order to reject them
+type sparse struct {
+	Count int `json:"count"`
+}
+
+type bufferView struct {
+	Buffer     int  `json:"buffer"`
+	ByteOffset int  `json:"byteOffset,omitempty"`
+	ByteLength int  `json:"byteLength"`
+	ByteStride int  `json:"byteStride,omitempty"`
+	Target     *int `json:"target,omitempty"`
+}
+
+type buffer struct {
+	URI        string `json:"uri,omitempty"`
+	ByteLength int    `json:"byteLength"`
+}
+
+// intPtr returns a pointer to the given value, used for optional indices
+func intPtr(v int) *int {
+	return &v
+}
+
+func float32Ptr(v float32) *float32 {
+	return &v
+}
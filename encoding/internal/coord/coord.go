@@ -0,0 +1,34 @@
+// Package coord formats REX coordinates incl. the offset of the coordinate system for text based formats
+package coord
+
+import (
+	"strconv"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Offset converts the offset of a coordinate system exactly into float64. The
+// decoders subtract the same float32 value, therefore the rounded offset must
+// be added again and not its shortest decimal representation.
+func Offset(o mgl32.Vec3) [3]float64 {
+	return [3]float64{float64(o[0]), float64(o[1]), float64(o[2])}
+}
+
+// Append appends the coordinate v+offset. The sum is written with the fewest
+// decimals which still result in v if the offset is subtracted again and the
+// difference is converted into float32. This avoids the rounding noise of the
+// float32 value (e.g. 500001.01 instead of 500001.0099999905).
+func Append(b []byte, v float32, offset float64) []byte {
+
+	if offset == 0 {
+		return strconv.AppendFloat(b, float64(v), 'f', -1, 32)
+	}
+	sum := float64(v) + offset
+	for n := 0; n < 17; n++ {
+		s := strconv.FormatFloat(sum, 'f', n, 64)
+		if p, err := strconv.ParseFloat(s, 64); err == nil && float32(p-offset) == v {
+			return append(b, s...)
+		}
+	}
+	return strconv.AppendFloat(b, sum, 'f', -1, 64)
+}
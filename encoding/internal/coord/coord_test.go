@@ -0,0 +1,54 @@
+package coord
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestAppend(t *testing.T) {
+
+	tests := []struct {
+		v        float32
+		offset   float64
+		expected string
+	}{
+		{1.01, 0, "1.01"},
+		{1.01, 500000, "500001.01"},
+		{1.5, 0.25, "1.75"},
+		{0, 100.125, "100.125"},
+		{-3.5, float64(float32(0.1)), "-3.4"},
+		{2, -67000, "-66998"},
+		{float32(500000.1 - float64(float32(500000.1))), float64(float32(500000.1)), "500000.1"},
+	}
+	for _, test := range tests {
+		if s := string(Append(nil, test.v, test.offset)); s != test.expected {
+			t.Errorf("Expected %s for %v + %v, got %s", test.expected, test.v, test.offset, s)
+		}
+	}
+}
+
+func TestAppendRoundtrip(t *testing.T) {
+
+	// the decoders subtract the float32 offset and store the difference as float32
+	for _, o := range []float64{500000.1, 5000000.37, -67123.456, 0.3} {
+		offset := float64(float32(o))
+		for _, x := range []float64{o, o + 0.001, o + 12.34, o - 98.7654} {
+			v := float32(x - offset)
+			p, err := strconv.ParseFloat(string(Append(nil, v, offset)), 64)
+			if err != nil {
+				t.Fatalf("TEST ERROR: %v", err)
+			}
+			if float32(p-offset) != v {
+				t.Errorf("%v with offset %v is written as %v", x, o, p)
+			}
+		}
+	}
+}
+
+func TestOffset(t *testing.T) {
+	if o := Offset(mgl32.Vec3{0.1, 84000.5, -2}); o != [3]float64{float64(float32(0.1)), 84000.5, -2} {
+		t.Errorf("Invalid offset %v", o)
+	}
+}
@@ -0,0 +1,290 @@
+// Package triangulate triangulates planar polygons with holes by ear clipping
+package triangulate
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// Polygon triangulates a planar polygon with holes. The first ring is the
+// exterior, all further rings are holes. The returned indices refer to the
+// concatenated vertices of all rings, every triangle starts with its smallest
+// index. The triangles have the orientation of the exterior ring, whose normal
+// is returned as well. Degenerated polygons return no triangles.
+func Polygon(rings [][]mgl64.Vec3) (mgl64.Vec3, [][3]int) {
+
+	if len(rings) == 0 || len(rings[0]) < 3 {
+		return mgl64.Vec3{}, nil
+	}
+	normal := newellNormal(rings[0])
+	if normal.Len() == 0 {
+		return normal, nil
+	}
+	normal = normal.Normalize()
+
+	// project onto the plane with the largest normal component
+	u, v, w := 0, 1, 2
+	switch {
+	case math.Abs(normal[0]) >= math.Abs(normal[1]) && math.Abs(normal[0]) >= math.Abs(normal[2]):
+		u, v, w = 1, 2, 0
+	case math.Abs(normal[1]) >= math.Abs(normal[2]):
+		u, v, w = 2, 0, 1
+	}
+	// mirror the projection of back facing polygons, therefore the exterior
+	// is counter clockwise and keeps the order of its vertices
+	if normal[w] < 0 {
+		u, v = v, u
+	}
+
+	var points []mgl64.Vec2
+	var polygons [][]int
+	for _, ring := range rings {
+		polygon := make([]int, len(ring))
+		for i, p := range ring {
+			polygon[i] = len(points)
+			points = append(points, mgl64.Vec2{p[u], p[v]})
+		}
+		polygons = append(polygons, polygon)
+	}
+
+	// the exterior is counter clockwise, holes are clockwise
+	outer := polygons[0]
+	if signedArea(points, outer) < 0 {
+		reverse(outer)
+	}
+	var holes [][]int
+	for _, hole := range polygons[1:] {
+		if len(hole) < 3 {
+			continue
+		}
+		if signedArea(points, hole) > 0 {
+			reverse(hole)
+		}
+		holes = append(holes, hole)
+	}
+	outer = bridgeHoles(points, outer, holes)
+
+	var triangles [][3]int
+	for _, t := range earClip(points, outer) {
+		// restore the orientation of the original polygon
+		a, b, c := flatten(rings, t[0]), flatten(rings, t[1]), flatten(rings, t[2])
+		if b.Sub(a).Cross(c.Sub(a)).Dot(normal) < 0 {
+			t[1], t[2] = t[2], t[1]
+		}
+		// start with the smallest index, which keeps the order of the input vertices
+		for t[0] > t[1] || t[0] > t[2] {
+			t[0], t[1], t[2] = t[1], t[2], t[0]
+		}
+		triangles = append(triangles, t)
+	}
+	return normal, triangles
+}
+
+// flatten returns the vertex with the given index of the concatenated rings
+func flatten(rings [][]mgl64.Vec3, idx int) mgl64.Vec3 {
+	for _, r := range rings {
+		if idx < len(r) {
+			return r[idx]
+		}
+		idx -= len(r)
+	}
+	return mgl64.Vec3{}
+}
+
+// newellNormal returns the (not normalized) normal of the ring, which is robust for non convex rings
+func newellNormal(ring []mgl64.Vec3) mgl64.Vec3 {
+	var n mgl64.Vec3
+	for i, a := range ring {
+		b := ring[(i+1)%len(ring)]
+		n[0] += (a[1] - b[1]) * (a[2] + b[2])
+		n[1] += (a[2] - b[2]) * (a[0] + b[0])
+		n[2] += (a[0] - b[0]) * (a[1] + b[1])
+	}
+	return n
+}
+
+func signedArea(points []mgl64.Vec2, polygon []int) float64 {
+	var area float64
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		area += points[a][0]*points[b][1] - points[b][0]*points[a][1]
+	}
+	return area / 2
+}
+
+func reverse(polygon []int) {
+	for i, j := 0, len(polygon)-1; i < j; i, j = i+1, j-1 {
+		polygon[i], polygon[j] = polygon[j], polygon[i]
+	}
+}
+
+// cross returns the z component of (b-a) x (c-b), positive for a left turn
+func cross(a, b, c mgl64.Vec2) float64 {
+	return (b[0]-a[0])*(c[1]-b[1]) - (b[1]-a[1])*(c[0]-b[0])
+}
+
+// bridgeHoles connects all holes with the exterior, which results in one
+// weakly simple polygon. The hole with the rightmost vertex is connected
+// first with a visible vertex of the polygon (D. Eberly, Triangulation by Ear
+// Clipping).
+func bridgeHoles(points []mgl64.Vec2, outer []int, holes [][]int) []int {
+
+	rightmost := func(hole []int) int {
+		best := 0
+		for i, idx := range hole {
+			if points[idx][0] > points[hole[best]][0] {
+				best = i
+			}
+		}
+		return best
+	}
+	sort.Slice(holes, func(i, j int) bool {
+		return points[holes[i][rightmost(holes[i])]][0] > points[holes[j][rightmost(holes[j])]][0]
+	})
+
+	for _, hole := range holes {
+		mi := rightmost(hole)
+		p := visibleVertex(points, outer, points[hole[mi]])
+
+		bridged := make([]int, 0, len(outer)+len(hole)+2)
+		bridged = append(bridged, outer[:p+1]...)
+		for i := 0; i <= len(hole); i++ {
+			bridged = append(bridged, hole[(mi+i)%len(hole)])
+		}
+		bridged = append(bridged, outer[p])
+		bridged = append(bridged, outer[p+1:]...)
+		outer = bridged
+	}
+	return outer
+}
+
+// visibleVertex returns the position of a polygon vertex which is visible from m
+func visibleVertex(points []mgl64.Vec2, polygon []int, m mgl64.Vec2) int {
+
+	// intersect the ray from m in +x direction with all edges
+	best := -1
+	bestX := math.Inf(1)
+	for i, a := range polygon {
+		pa, pb := points[a], points[polygon[(i+1)%len(polygon)]]
+		if (pa[1] > m[1]) == (pb[1] > m[1]) || pa[1] == pb[1] {
+			continue
+		}
+		x := pa[0] + (m[1]-pa[1])*(pb[0]-pa[0])/(pb[1]-pa[1])
+		if x < m[0] || x >= bestX {
+			continue
+		}
+		bestX = x
+		// candidate is the endpoint with the larger x
+		best = i
+		if pb[0] > pa[0] {
+			best = (i + 1) % len(polygon)
+		}
+	}
+
+	if best < 0 {
+		// no intersection (invalid hole), use the closest vertex
+		for i, idx := range polygon {
+			if best < 0 || points[idx].Sub(m).Len() < points[polygon[best]].Sub(m).Len() {
+				best = i
+			}
+		}
+		return best
+	}
+
+	// a reflex vertex within the triangle m, intersection, candidate may hide the
+	// candidate, the one with the smallest angle to the ray is visible
+	intersection := mgl64.Vec2{bestX, m[1]}
+	candidate := points[polygon[best]]
+	bestAngle := math.Inf(1)
+	if candidate[1] != m[1] {
+		for i, idx := range polygon {
+			p := points[idx]
+			prev := points[polygon[(i+len(polygon)-1)%len(polygon)]]
+			next := points[polygon[(i+1)%len(polygon)]]
+			if i == best || cross(prev, p, next) > 0 || !inTriangle(p, m, intersection, candidate) {
+				continue
+			}
+			d := p.Sub(m)
+			angle := math.Abs(math.Atan2(d[1], d[0]))
+			if angle < bestAngle || (angle == bestAngle && d.Len() < points[polygon[best]].Sub(m).Len()) {
+				bestAngle = angle
+				best = i
+			}
+		}
+	}
+	return best
+}
+
+// inTriangle returns true if p is inside or on the border of the triangle
+func inTriangle(p, a, b, c mgl64.Vec2) bool {
+	d1 := cross(a, b, p)
+	d2 := cross(b, c, p)
+	d3 := cross(c, a, p)
+	neg := d1 < 0 || d2 < 0 || d3 < 0
+	pos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(neg && pos)
+}
+
+// earClip triangulates the counter clockwise polygon
+func earClip(points []mgl64.Vec2, polygon []int) [][3]int {
+
+	polygon = append([]int{}, polygon...)
+	var triangles [][3]int
+	for len(polygon) > 3 {
+		n := len(polygon)
+		ear := -1
+		// starting with the second vertex results in a fan for convex polygons
+		for k := 1; k <= n && ear < 0; k++ {
+			if isEar(points, polygon, k%n) {
+				ear = k % n
+			}
+		}
+		if ear < 0 {
+			// degenerated polygon, remove a collinear vertex or clip anyway
+			ear = 0
+			for i := 0; i < n; i++ {
+				a, b, c := points[polygon[(i+n-1)%n]], points[polygon[i]], points[polygon[(i+1)%n]]
+				if math.Abs(cross(a, b, c)) < 1e-12 {
+					polygon = append(polygon[:i], polygon[i+1:]...)
+					ear = -1
+					break
+				}
+			}
+			if ear < 0 {
+				continue
+			}
+		}
+		triangles = append(triangles, [3]int{polygon[(ear+n-1)%n], polygon[ear], polygon[(ear+1)%n]})
+		polygon = append(polygon[:ear], polygon[ear+1:]...)
+	}
+	if len(polygon) == 3 && cross(points[polygon[0]], points[polygon[1]], points[polygon[2]]) != 0 {
+		triangles = append(triangles, [3]int{polygon[0], polygon[1], polygon[2]})
+	}
+	return triangles
+}
+
+// isEar returns true if the vertex i is convex and no other vertex is within its triangle
+func isEar(points []mgl64.Vec2, polygon []int, i int) bool {
+
+	n := len(polygon)
+	a, b, c := points[polygon[(i+n-1)%n]], points[polygon[i]], points[polygon[(i+1)%n]]
+	if cross(a, b, c) <= 0 {
+		return false
+	}
+	for j, idx := range polygon {
+		if j == i || j == (i+n-1)%n || j == (i+1)%n {
+			continue
+		}
+		// bridge vertices are duplicated
+		p := points[idx]
+		if p == a || p == b || p == c {
+			continue
+		}
+		if inTriangle(p, a, b, c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package triangulate
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// area returns the area of all triangles and checks their orientation
+func area(t *testing.T, rings [][]mgl64.Vec3) (float64, int) {
+
+	normal, triangles := Polygon(rings)
+	var sum float64
+	for _, tri := range triangles {
+		a, b, c := flatten(rings, tri[0]), flatten(rings, tri[1]), flatten(rings, tri[2])
+		n := b.Sub(a).Cross(c.Sub(a))
+		if n.Dot(normal) < 0 {
+			t.Errorf("Triangle %v has the wrong orientation", tri)
+		}
+		sum += n.Len() / 2
+	}
+	return sum, len(triangles)
+}
+
+func TestPolygon(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		rings     [][]mgl64.Vec3
+		area      float64
+		triangles int
+	}{
+		{
+			name:      "square",
+			rings:     [][]mgl64.Vec3{{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}},
+			area:      1,
+			triangles: 2,
+		},
+		{
+			name:      "concave wall (clockwise)",
+			rings:     [][]mgl64.Vec3{{{0, 0, 0}, {0, 0, 2}, {1, 0, 2}, {1, 0, 1}, {2, 0, 1}, {2, 0, 0}}},
+			area:      3,
+			triangles: 4,
+		},
+		{
+			name: "roof with chimney hole",
+			rings: [][]mgl64.Vec3{
+				{{0, 0, 10}, {4, 0, 10}, {4, 4, 14}, {0, 4, 14}},
+				{{1, 1, 11}, {1, 2, 12}, {2, 2, 12}, {2, 1, 11}},
+			},
+			area:      16*math.Sqrt2 - math.Sqrt2,
+			triangles: 8,
+		},
+		{
+			name: "two holes",
+			rings: [][]mgl64.Vec3{
+				{{0, 0, 0}, {10, 0, 0}, {10, 0, 5}, {0, 0, 5}},
+				{{1, 0, 1}, {3, 0, 1}, {3, 0, 3}, {1, 0, 3}},
+				{{6, 0, 1}, {8, 0, 1}, {8, 0, 4}, {6, 0, 4}},
+			},
+			area:      50 - 4 - 6,
+			triangles: 14,
+		},
+		{
+			name:      "collinear vertex",
+			rings:     [][]mgl64.Vec3{{{0, 0, 0}, {1, 0, 0}, {2, 0, 0}, {2, 1, 0}, {0, 1, 0}}},
+			area:      2,
+			triangles: 3,
+		},
+		{
+			name:  "degenerated",
+			rings: [][]mgl64.Vec3{{{0, 0, 0}, {1, 0, 0}, {2, 0, 0}}},
+		},
+	}
+
+	for _, test := range tests {
+		a, n := area(t, test.rings)
+		if math.Abs(a-test.area) > 1e-9 {
+			t.Errorf("%s: expected area %v, got %v", test.name, test.area, a)
+		}
+		if n != test.triangles {
+			t.Errorf("%s: expected %d triangles, got %d", test.name, test.triangles, n)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package las
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// DefaultChunkSize is the default maximum number of points of a point list
+const DefaultChunkSize = 1000000
+
+// DecoderOptions control the LAS import
+type DecoderOptions struct {
+	// ChunkSize is the maximum number of points of a point list, larger point
+	// clouds are split into several point lists. If 0 the DefaultChunkSize is used.
+	ChunkSize int
+	// ColorDepth is the number of bits of the RGB values (8 or 16). If 0 the
+	// 16 bit of the specification are used.
+	ColorDepth int
+}
+
+// Decoder reads a LAS file (version 1.2 to 1.4, point formats 0-3 and 6-8)
+// and converts the points into point lists.
+//
+// The points are stored relative to the LAS offset, which is stored as offset
+// of the REX coordinate system together with the EPSG code of the VLRs. This
+// keeps the precision of the float32 coordinates for large (e.g. UTM) values.
+// RGB values are normalized to 0..1. The specification requires 16 bit values,
+// files of writers which store 8 bit values must be read with a ColorDepth of 8.
+// Extended VLRs are not read.
+type Decoder struct {
+	r      *bufio.Reader
+	opts   DecoderOptions
+	header *Header
+
+	origin    [3]float64 // offset of the coordinate system
+	remaining uint64
+	id        uint64
+	record    []byte
+}
+
+// NewDecoder creates a new LAS decoder
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, DecoderOptions{})
+}
+
+// NewDecoderWithOptions creates a new LAS decoder with the given options
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	if opts.ColorDepth == 0 {
+		opts.ColorDepth = 16
+	}
+	return &Decoder{r: bufio.NewReaderSize(r, 64*1024), opts: opts}
+}
+
+// ReadFile reads the given LAS file
+func ReadFile(name string) (*rex.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewDecoder(f).Decode()
+}
+
+// Header reads the LAS header and the VLRs, it is called automatically by Next
+func (dec *Decoder) Header() (*Header, error) {
+
+	if dec.header != nil {
+		return dec.header, nil
+	}
+	if dec.opts.ColorDepth != 8 && dec.opts.ColorDepth != 16 {
+		return nil, fmt.Errorf("Invalid color depth %d, must be 8 or 16", dec.opts.ColorDepth)
+	}
+	h, read, err := readHeader(dec.r)
+	if err != nil {
+		return nil, err
+	}
+	n, err := h.readVLRs(dec.r)
+	read += n
+	if err != nil {
+		return nil, err
+	}
+	if int64(h.offsetToPoints) < read {
+		return nil, fmt.Errorf("Invalid offset to point data %d", h.offsetToPoints)
+	}
+	if _, err := io.CopyN(ioutil.Discard, dec.r, int64(h.offsetToPoints)-read); err != nil {
+		return nil, fmt.Errorf("Reading LAS file failed: %w", err)
+	}
+
+	dec.header = h
+	dec.remaining = h.PointCount
+	dec.record = make([]byte, h.PointRecordLength)
+	cs := dec.CoordinateSystem()
+	for i := 0; i < 3; i++ {
+		dec.origin[i] = float64(cs.Offset[i])
+	}
+	return h, nil
+}
+
+// CoordinateSystem returns the coordinate system of the point lists, the
+// header must be read before.
+func (dec *Decoder) CoordinateSystem() rex.CoordinateSystem {
+
+	cs := rex.CoordinateSystem{}
+	if dec.header == nil {
+		return cs
+	}
+	if dec.header.EPSG != 0 {
+		cs.SRID = dec.header.EPSG
+		cs.Authority = "EPSG"
+	}
+	for i := 0; i < 3; i++ {
+		cs.Offset[i] = float32(dec.header.Offset[i])
+	}
+	return cs
+}
+
+// Next returns the next point list with at most ChunkSize points. At the end io.EOF is returned.
+func (dec *Decoder) Next() (*rex.PointList, error) {
+
+	h, err := dec.Header()
+	if err != nil {
+		return nil, err
+	}
+	if dec.remaining == 0 {
+		return nil, io.EOF
+	}
+
+	n := dec.opts.ChunkSize
+	if uint64(n) > dec.remaining {
+		n = int(dec.remaining)
+	}
+	dec.id++
+	pl := &rex.PointList{ID: dec.id, Points: make([]mgl32.Vec3, n)}
+
+	var rgb [][3]uint16
+	rgbOffset, hasColors := rgbOffsets[h.PointFormat]
+	if hasColors {
+		rgb = make([][3]uint16, n)
+	}
+
+	for i := 0; i < n; i++ {
+		if _, err := io.ReadFull(dec.r, dec.record); err != nil {
+			return nil, fmt.Errorf("Reading point %d failed: %w", h.PointCount-dec.remaining, err)
+		}
+		dec.remaining--
+
+		for j := 0; j < 3; j++ {
+			v := float64(int32(binary.LittleEndian.Uint32(dec.record[j*4:])))*h.Scale[j] + h.Offset[j]
+			pl.Points[i][j] = float32(v - dec.origin[j])
+		}
+		if hasColors {
+			for j := 0; j < 3; j++ {
+				rgb[i][j] = binary.LittleEndian.Uint16(dec.record[rgbOffset+j*2:])
+			}
+		}
+	}
+
+	if hasColors {
+		pl.Colors = dec.normalizeColors(rgb)
+	}
+	return pl, nil
+}
+
+// normalizeColors converts the RGB values with the color depth into 0..1
+func (dec *Decoder) normalizeColors(rgb [][3]uint16) []mgl32.Vec3 {
+
+	scale := float32(1.0 / 65535)
+	if dec.opts.ColorDepth == 8 {
+		scale = 1.0 / 255
+	}
+
+	colors := make([]mgl32.Vec3, len(rgb))
+	for i, c := range rgb {
+		colors[i] = mgl32.Vec3{
+			mgl32.Clamp(float32(c[0])*scale, 0, 1),
+			mgl32.Clamp(float32(c[1])*scale, 0, 1),
+			mgl32.Clamp(float32(c[2])*scale, 0, 1),
+		}
+	}
+	return colors
+}
+
+// Decode reads all points and returns a REX file incl. the coordinate system
+func (dec *Decoder) Decode() (*rex.File, error) {
+
+	if _, err := dec.Header(); err != nil {
+		return nil, err
+	}
+	f := &rex.File{CoordinateSystem: dec.CoordinateSystem()}
+	for {
+		pl, err := dec.Next()
+		if err == io.EOF {
+			return f, nil
+		} else if err != nil {
+			return nil, err
+		}
+		f.PointLists = append(f.PointLists, *pl)
+	}
+}
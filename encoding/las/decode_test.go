@@ -0,0 +1,225 @@
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type testPoint struct {
+	X, Y, Z int32
+	R, G, B uint16
+}
+
+// writeLAS creates a LAS file with the given version, point format and one projection VLR
+func writeLAS(minor, format uint8, recordID uint16, vlrData []byte, points []testPoint) []byte {
+
+	headerSize := uint16(headerSize12)
+	if minor == 4 {
+		headerSize = headerSize14
+	}
+	recordLength := uint16(pointSizes[format] + 4) // with extra bytes
+	offsetToPoints := uint32(headerSize) + vlrSize + uint32(len(vlrData))
+
+	raw := rawHeader{
+		VersionMajor:      1,
+		VersionMinor:      minor,
+		HeaderSize:        headerSize,
+		OffsetToPoints:    offsetToPoints,
+		VLRCount:          1,
+		PointFormat:       format,
+		PointRecordLength: recordLength,
+		LegacyPointCount:  uint32(len(points)),
+		Scale:             [3]float64{0.01, 0.01, 0.001},
+		Offset:            [3]float64{500000, 5000000, 300},
+	}
+	copy(raw.Signature[:], "LASF")
+	copy(raw.SystemIdentifier[:], "test")
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, raw)
+	if minor == 4 {
+		binary.Write(&buf, binary.LittleEndian, raw14Header{PointCount: uint64(len(points))})
+	}
+
+	var vlr struct {
+		Reserved    uint16
+		UserID      [16]byte
+		RecordID    uint16
+		Length      uint16
+		Description [32]byte
+	}
+	copy(vlr.UserID[:], "LASF_Projection")
+	vlr.RecordID = recordID
+	vlr.Length = uint16(len(vlrData))
+	binary.Write(&buf, binary.LittleEndian, vlr)
+	buf.Write(vlrData)
+
+	rgbOffset, hasColors := rgbOffsets[format]
+	for _, p := range points {
+		record := make([]byte, recordLength)
+		binary.LittleEndian.PutUint32(record[0:], uint32(p.X))
+		binary.LittleEndian.PutUint32(record[4:], uint32(p.Y))
+		binary.LittleEndian.PutUint32(record[8:], uint32(p.Z))
+		if hasColors {
+			binary.LittleEndian.PutUint16(record[rgbOffset:], p.R)
+			binary.LittleEndian.PutUint16(record[rgbOffset+2:], p.G)
+			binary.LittleEndian.PutUint16(record[rgbOffset+4:], p.B)
+		}
+		buf.Write(record)
+	}
+	return buf.Bytes()
+}
+
+func geoKeys(keys ...uint16) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, []uint16{1, 1, 0, uint16(len(keys) / 4)})
+	binary.Write(&buf, binary.LittleEndian, keys)
+	return buf.Bytes()
+}
+
+func TestDecodeLAS12(t *testing.T) {
+
+	points := []testPoint{
+		{X: 100, Y: 200, Z: 300, R: 65535, G: 0, B: 32768},
+		{X: -100, Y: 0, Z: 0, R: 0, G: 65535, B: 0},
+		{X: 12345, Y: 67890, Z: -5000, R: 0, G: 0, B: 65535},
+	}
+	// geographic and projected key, the projected one is used
+	keys := geoKeys(1024, 0, 1, 1, geographicTypeGeoKey, 0, 1, 4258, projectedCSTypeGeoKey, 0, 1, 25832)
+	data := writeLAS(2, 3, geoKeyDirectoryRecord, keys, points)
+
+	dec := NewDecoderWithOptions(bytes.NewReader(data), DecoderOptions{ChunkSize: 2})
+	h, err := dec.Header()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if h.PointCount != 3 || h.EPSG != 25832 || h.SystemIdentifier != "test" || !h.HasColors() {
+		t.Errorf("Invalid header %+v", h)
+	}
+
+	f, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	cs := f.CoordinateSystem
+	if cs.SRID != 25832 || cs.Authority != "EPSG" || cs.Offset != (mgl32.Vec3{500000, 5000000, 300}) {
+		t.Errorf("Invalid coordinate system %v", cs)
+	}
+	if len(f.PointLists) != 2 || len(f.PointLists[0].Points) != 2 || len(f.PointLists[1].Points) != 1 {
+		t.Fatalf("Expected 2 point lists with 2 and 1 points")
+	}
+	if f.PointLists[0].ID == f.PointLists[1].ID {
+		t.Errorf("Point lists must have unique IDs")
+	}
+
+	// points are relative to the offset
+	if p := f.PointLists[0].Points[0]; !p.ApproxEqual(mgl32.Vec3{1, 2, 0.3}) {
+		t.Errorf("Invalid point %v", p)
+	}
+	if p := f.PointLists[1].Points[0]; !p.ApproxEqualThreshold(mgl32.Vec3{123.45, 678.9, -5}, 1e-4) {
+		t.Errorf("Invalid point %v", p)
+	}
+	if c := f.PointLists[0].Colors[0]; !c.ApproxEqualThreshold(mgl32.Vec3{1, 0, 0.5}, 1e-4) {
+		t.Errorf("Invalid color %v", c)
+	}
+}
+
+func TestDecodeLAS14(t *testing.T) {
+
+	wkt := []byte(`PROJCS["ETRS89 / UTM zone 33N",GEOGCS["ETRS89",AUTHORITY["EPSG","4258"]],AUTHORITY["EPSG","25833"]]` + "\x00")
+	points := []testPoint{{X: 1, Y: 2, Z: 3, R: 65535, G: 128 * 257, B: 0}}
+
+	for _, format := range []uint8{6, 7, 8} {
+		f, err := NewDecoder(bytes.NewReader(writeLAS(4, format, wktRecord, wkt, points))).Decode()
+		if err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+		if f.CoordinateSystem.SRID != 25833 {
+			t.Errorf("Format %d: invalid SRID %d", format, f.CoordinateSystem.SRID)
+		}
+		pl := f.PointLists[0]
+		if len(pl.Points) != 1 || !pl.Points[0].ApproxEqual(mgl32.Vec3{0.01, 0.02, 0.003}) {
+			t.Errorf("Format %d: invalid points %v", format, pl.Points)
+		}
+		if format == 6 {
+			if pl.Colors != nil {
+				t.Errorf("Format 6 has no colors")
+			}
+			continue
+		}
+		if !pl.Colors[0].ApproxEqualThreshold(mgl32.Vec3{1, 128.0 / 255, 0}, 1e-5) {
+			t.Errorf("Format %d: invalid color %v", format, pl.Colors[0])
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+
+	valid := writeLAS(2, 0, 0, nil, []testPoint{{}, {}})
+
+	unsupported := append([]byte{}, valid...)
+	unsupported[104] = 4 // point format 4 (waveform)
+	laz := append([]byte{}, valid...)
+	laz[104] = 0x80
+	version := append([]byte{}, valid...)
+	version[25] = 1
+
+	tests := map[string][]byte{
+		"truncated":   valid[:len(valid)-5],
+		"signature":   append([]byte("LASX"), valid[4:]...),
+		"format":      unsupported,
+		"laz":         laz,
+		"version 1.1": version,
+		"header":      valid[:100],
+	}
+	for name, data := range tests {
+		if _, err := NewDecoder(bytes.NewReader(data)).Decode(); err == nil || err == io.EOF {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+}
+
+func TestDecodeColorDepth(t *testing.T) {
+
+	points := []testPoint{
+		{R: 255, G: 0, B: 0},
+		{R: 65535, G: 0, B: 0},
+		{R: 128, G: 0, B: 0},
+	}
+	data := writeLAS(2, 2, 0, nil, points)
+
+	// 16 bit by default for all chunks, independent of the values
+	f, err := NewDecoderWithOptions(bytes.NewReader(data), DecoderOptions{ChunkSize: 1}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	expected := []float32{255.0 / 65535, 1, 128.0 / 65535}
+	for i, pl := range f.PointLists {
+		if math.Abs(float64(pl.Colors[0][0]-expected[i])) > 1e-6 {
+			t.Errorf("Chunk %d: expected red %v, got %v", i, expected[i], pl.Colors[0][0])
+		}
+	}
+
+	// 8 bit colors are clamped
+	f, err = NewDecoderWithOptions(bytes.NewReader(data), DecoderOptions{ColorDepth: 8}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	expected = []float32{1, 1, 128.0 / 255}
+	for i, c := range f.PointLists[0].Colors {
+		if math.Abs(float64(c[0]-expected[i])) > 1e-6 {
+			t.Errorf("Point %d: expected red %v, got %v", i, expected[i], c[0])
+		}
+	}
+
+	for _, depth := range []int{-1, 1, 12, 24} {
+		if _, err := NewDecoderWithOptions(bytes.NewReader(data), DecoderOptions{ColorDepth: depth}).Decode(); err == nil {
+			t.Errorf("Expected error for color depth %d", depth)
+		}
+	}
+}
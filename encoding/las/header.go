@@ -0,0 +1,250 @@
+// Package las reads LAS point clouds (versions 1.2 to 1.4) into REX point lists
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+)
+
+const (
+	headerSize12 = 227
+	headerSize14 = 375
+	vlrSize      = 54
+
+	geoKeyDirectoryRecord = 34735
+	wktRecord             = 2112
+
+	projectedCSTypeGeoKey  = 3072
+	geographicTypeGeoKey   = 2048
+	userDefinedGeoKeyValue = 32767
+)
+
+// pointSizes contains the minimum size of the supported point data record formats
+var pointSizes = map[uint8]int{0: 20, 1: 28, 2: 26, 3: 34, 6: 30, 7: 36, 8: 38}
+
+// rgbOffsets contains the offset of the RGB values within the point record
+var rgbOffsets = map[uint8]int{2: 20, 3: 28, 7: 30, 8: 30}
+
+// Header contains the relevant information of the LAS public header block and the VLRs
+type Header struct {
+	VersionMajor       uint8
+	VersionMinor       uint8
+	SystemIdentifier   string
+	GeneratingSoftware string
+	PointFormat        uint8
+	PointRecordLength  uint16
+	PointCount         uint64
+	Scale              [3]float64
+	Offset             [3]float64
+	Min                [3]float64
+	Max                [3]float64
+	EPSG               uint32 // 0 if the VLRs do not declare an EPSG code
+
+	offsetToPoints uint32
+	vlrCount       uint32
+}
+
+// HasColors returns true if the point format contains RGB values
+func (h *Header) HasColors() bool {
+	_, ok := rgbOffsets[h.PointFormat]
+	return ok
+}
+
+// rawHeader is the binary layout of the LAS 1.2 header, newer versions append fields
+type rawHeader struct {
+	Signature          [4]byte
+	FileSourceID       uint16
+	GlobalEncoding     uint16
+	GUID               [16]byte
+	VersionMajor       uint8
+	VersionMinor       uint8
+	SystemIdentifier   [32]byte
+	GeneratingSoftware [32]byte
+	DayOfYear          uint16
+	Year               uint16
+	HeaderSize         uint16
+	OffsetToPoints     uint32
+	VLRCount           uint32
+	PointFormat        uint8
+	PointRecordLength  uint16
+	LegacyPointCount   uint32
+	LegacyByReturn     [5]uint32
+	Scale              [3]float64
+	Offset             [3]float64
+	MaxX, MinX         float64
+	MaxY, MinY         float64
+	MaxZ, MinZ         float64
+}
+
+// raw14Header contains the additional fields of LAS 1.4 (incl. the waveform offset of LAS 1.3)
+type raw14Header struct {
+	StartOfWaveform   uint64
+	StartOfFirstEVLR  uint64
+	EVLRCount         uint32
+	PointCount        uint64
+	PointCountsReturn [15]uint64
+}
+
+// readHeader reads the public header block and returns the number of consumed bytes
+func readHeader(r io.Reader) (*Header, int64, error) {
+
+	var raw rawHeader
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, 0, fmt.Errorf("Reading LAS header failed: %w", err)
+	}
+	if string(raw.Signature[:]) != "LASF" {
+		return nil, 0, fmt.Errorf("Not a LAS file")
+	}
+	if raw.VersionMajor != 1 || raw.VersionMinor < 2 || raw.VersionMinor > 4 {
+		return nil, 0, fmt.Errorf("LAS version %d.%d is not supported", raw.VersionMajor, raw.VersionMinor)
+	}
+	// the upper bits are used by LAZ
+	if raw.PointFormat&0xc0 != 0 {
+		return nil, 0, fmt.Errorf("Compressed LAS (LAZ) files are not supported")
+	}
+	size, ok := pointSizes[raw.PointFormat]
+	if !ok {
+		return nil, 0, fmt.Errorf("LAS point format %d is not supported", raw.PointFormat)
+	}
+	if int(raw.PointRecordLength) < size {
+		return nil, 0, fmt.Errorf("LAS point record length %d is too small for format %d", raw.PointRecordLength, raw.PointFormat)
+	}
+
+	h := &Header{
+		VersionMajor:       raw.VersionMajor,
+		VersionMinor:       raw.VersionMinor,
+		SystemIdentifier:   cString(raw.SystemIdentifier[:]),
+		GeneratingSoftware: cString(raw.GeneratingSoftware[:]),
+		PointFormat:        raw.PointFormat,
+		PointRecordLength:  raw.PointRecordLength,
+		PointCount:         uint64(raw.LegacyPointCount),
+		Scale:              raw.Scale,
+		Offset:             raw.Offset,
+		Min:                [3]float64{raw.MinX, raw.MinY, raw.MinZ},
+		Max:                [3]float64{raw.MaxX, raw.MaxY, raw.MaxZ},
+		offsetToPoints:     raw.OffsetToPoints,
+		vlrCount:           raw.VLRCount,
+	}
+	read := int64(headerSize12)
+
+	if raw.VersionMinor == 4 {
+		if raw.HeaderSize < headerSize14 {
+			return nil, 0, fmt.Errorf("LAS 1.4 header is too small (%d bytes)", raw.HeaderSize)
+		}
+		var raw14 raw14Header
+		if err := binary.Read(r, binary.LittleEndian, &raw14); err != nil {
+			return nil, 0, fmt.Errorf("Reading LAS 1.4 header failed: %w", err)
+		}
+		read = headerSize14
+		if raw14.PointCount > 0 {
+			h.PointCount = raw14.PointCount
+		}
+	}
+
+	if int64(raw.HeaderSize) < read {
+		return nil, 0, fmt.Errorf("Invalid LAS header size %d", raw.HeaderSize)
+	}
+	// skip the remaining header (user defined data)
+	if _, err := io.CopyN(ioutil.Discard, r, int64(raw.HeaderSize)-read); err != nil {
+		return nil, 0, fmt.Errorf("Reading LAS header failed: %w", err)
+	}
+	return h, int64(raw.HeaderSize), nil
+}
+
+// readVLRs reads the variable length records and extracts the EPSG code. It
+// returns the number of consumed bytes.
+func (h *Header) readVLRs(r io.Reader) (int64, error) {
+
+	var read int64
+	for i := uint32(0); i < h.vlrCount; i++ {
+		var vlr struct {
+			Reserved    uint16
+			UserID      [16]byte
+			RecordID    uint16
+			Length      uint16
+			Description [32]byte
+		}
+		if err := binary.Read(r, binary.LittleEndian, &vlr); err != nil {
+			return read, fmt.Errorf("Reading VLR %d failed: %w", i, err)
+		}
+		data := make([]byte, vlr.Length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return read, fmt.Errorf("Reading VLR %d failed: %w", i, err)
+		}
+		read += vlrSize + int64(vlr.Length)
+
+		if cString(vlr.UserID[:]) != "LASF_Projection" {
+			continue
+		}
+		switch vlr.RecordID {
+		case geoKeyDirectoryRecord:
+			if epsg := geoKeyEPSG(data); epsg != 0 {
+				h.EPSG = epsg
+			}
+		case wktRecord:
+			if epsg := wktEPSG(data); epsg != 0 {
+				h.EPSG = epsg
+			}
+		}
+	}
+	return read, nil
+}
+
+// geoKeyEPSG returns the EPSG code of the GeoTIFF key directory. The projected
+// coordinate system is preferred over the geographic one.
+func geoKeyEPSG(data []byte) uint32 {
+
+	keys := make([]uint16, len(data)/2)
+	binary.Read(bytes.NewReader(data), binary.LittleEndian, keys)
+	if len(keys) < 4 {
+		return 0
+	}
+
+	var projected, geographic uint16
+	n := int(keys[3])
+	for i := 0; i < n && 4+i*4+3 < len(keys); i++ {
+		key := keys[4+i*4:]
+		// only values which are stored directly in the directory (location 0)
+		if key[1] != 0 || key[3] == 0 || key[3] == userDefinedGeoKeyValue {
+			continue
+		}
+		switch key[0] {
+		case projectedCSTypeGeoKey:
+			projected = key[3]
+		case geographicTypeGeoKey:
+			geographic = key[3]
+		}
+	}
+	if projected != 0 {
+		return uint32(projected)
+	}
+	return uint32(geographic)
+}
+
+var wktAuthority = regexp.MustCompile(`AUTHORITY\["EPSG",\s*"?(\d+)"?\]`)
+
+// wktEPSG returns the EPSG code of the OGC WKT, which is the last authority of the definition
+func wktEPSG(data []byte) uint32 {
+	matches := wktAuthority.FindAllSubmatch(data, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+	epsg, err := strconv.ParseUint(string(matches[len(matches)-1][1]), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(epsg)
+}
+
+// cString returns the string up to the first 0 byte
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
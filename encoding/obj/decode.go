@@ -0,0 +1,374 @@
+// Package obj converts Wavefront OBJ/MTL files from and to REX files
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/roboticeyes/gorex/encoding/internal/triangulate"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// DecoderOptions control the OBJ import
+type DecoderOptions struct {
+	// Open opens the files which are referenced by the OBJ file (material
+	// libraries and texture maps). If nil, materials and textures are not imported.
+	Open func(name string) (io.ReadCloser, error)
+}
+
+// Decoder reads a Wavefront OBJ file and converts it into a REX file.
+// Faces become meshes (one mesh per object/group and material), lines become
+// line sets, and the referenced materials and textures become material and
+// image blocks.
+type Decoder struct {
+	r    io.Reader
+	opts DecoderOptions
+}
+
+// NewDecoder creates a new OBJ decoder w/o material support
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// NewDecoderWithOptions creates a new OBJ decoder with the given options
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	return &Decoder{r: r, opts: opts}
+}
+
+// ReadFile reads the given OBJ file including all referenced materials and
+// textures, which are resolved relative to the OBJ file.
+func ReadFile(name string) (*rex.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(name)
+	return NewDecoderWithOptions(f, DecoderOptions{
+		Open: func(ref string) (io.ReadCloser, error) {
+			return os.Open(filepath.Join(dir, filepath.FromSlash(ref)))
+		},
+	}).Decode()
+}
+
+// vertex references the position, texture coordinate and normal of a face corner (0 based, -1 if not set)
+type vertex struct {
+	v, vt, vn int
+}
+
+// meshBuilder de-indexes the OBJ vertices, because REX uses one single index
+// for all attributes of a vertex
+type meshBuilder struct {
+	mesh       rex.Mesh
+	indices    map[vertex]uint32
+	hasTexture bool
+	hasNormals bool
+}
+
+// decoder holds the state while parsing
+type decoder struct {
+	opts DecoderOptions
+	file *rex.File
+	id   uint64
+
+	positions []mgl32.Vec3
+	colors    []mgl32.Vec3 // optional vertex colors (v x y z r g b)
+	texCoords []mgl32.Vec2
+	normals   []mgl32.Vec3
+
+	materials map[string]*material
+	textures  map[string]uint64 // image IDs of the loaded textures by file name
+	current   *meshBuilder
+	name      string
+	material  string
+	meshes    []*meshBuilder
+}
+
+// Decode reads the OBJ file and returns the REX file
+func (dec *Decoder) Decode() (*rex.File, error) {
+
+	d := &decoder{
+		opts:      dec.opts,
+		file:      &rex.File{},
+		materials: make(map[string]*material),
+		textures:  make(map[string]uint64),
+	}
+
+	scanner := bufio.NewScanner(dec.r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if err := d.parseLine(scanner.Text()); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	d.finish()
+	return d.file, nil
+}
+
+func (d *decoder) nextID() uint64 {
+	d.id++
+	return d.id
+}
+
+func (d *decoder) parseLine(line string) error {
+
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	switch fields[0] {
+	case "v":
+		values, err := parseFloats(fields[1:], 3)
+		if err != nil {
+			return fmt.Errorf("invalid vertex: %v", err)
+		}
+		d.positions = append(d.positions, mgl32.Vec3{values[0], values[1], values[2]})
+		if len(values) >= 6 {
+			d.colors = append(d.colors, mgl32.Vec3{values[3], values[4], values[5]})
+		}
+	case "vt":
+		values, err := parseFloats(fields[1:], 1)
+		if err != nil {
+			return fmt.Errorf("invalid texture coordinate: %v", err)
+		}
+		vt := mgl32.Vec2{values[0], 0}
+		if len(values) > 1 {
+			vt[1] = values[1]
+		}
+		d.texCoords = append(d.texCoords, vt)
+	case "vn":
+		values, err := parseFloats(fields[1:], 3)
+		if err != nil {
+			return fmt.Errorf("invalid normal: %v", err)
+		}
+		d.normals = append(d.normals, mgl32.Vec3{values[0], values[1], values[2]})
+	case "f":
+		return d.parseFace(fields[1:])
+	case "l":
+		return d.parseLineElement(fields[1:])
+	case "o", "g":
+		d.name = strings.Join(fields[1:], " ")
+		d.current = nil
+	case "usemtl":
+		d.material = strings.Join(fields[1:], " ")
+		d.current = nil
+	case "mtllib":
+		for _, lib := range fields[1:] {
+			if err := d.loadMaterialLibrary(lib); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseFace triangulates the polygon by ear clipping (concave polygons are
+// common) and adds it to the current mesh
+func (d *decoder) parseFace(fields []string) error {
+
+	if len(fields) < 3 {
+		return fmt.Errorf("face with %d vertices", len(fields))
+	}
+	corners := make([]vertex, len(fields))
+	for i, f := range fields {
+		v, err := d.parseVertex(f)
+		if err != nil {
+			return err
+		}
+		corners[i] = v
+	}
+
+	mb := d.meshBuilder()
+	indices := make([]uint32, len(corners))
+	for i, c := range corners {
+		indices[i] = mb.index(d, c)
+	}
+	for _, t := range d.triangulate(corners) {
+		mb.mesh.Triangles = append(mb.mesh.Triangles, rex.Triangle{V0: indices[t[0]], V1: indices[t[1]], V2: indices[t[2]]})
+	}
+	return nil
+}
+
+// triangulate returns the triangles of the polygon as corner indices. Degenerated
+// polygons are triangulated as fan.
+func (d *decoder) triangulate(corners []vertex) [][3]int {
+
+	if len(corners) > 3 {
+		ring := make([]mgl64.Vec3, len(corners))
+		for i, c := range corners {
+			p := d.positions[c.v]
+			ring[i] = mgl64.Vec3{float64(p[0]), float64(p[1]), float64(p[2])}
+		}
+		if _, triangles := triangulate.Polygon([][]mgl64.Vec3{ring}); len(triangles) > 0 {
+			return triangles
+		}
+	}
+	var triangles [][3]int
+	for i := 1; i+1 < len(corners); i++ {
+		triangles = append(triangles, [3]int{0, i, i + 1})
+	}
+	return triangles
+}
+
+// parseLineElement converts a polyline into a line set
+func (d *decoder) parseLineElement(fields []string) error {
+
+	if len(fields) < 2 {
+		return fmt.Errorf("line with %d vertices", len(fields))
+	}
+	ls := rex.LineSet{ID: d.nextID(), Colors: mgl32.Vec4{1, 1, 1, 1}}
+	if m, ok := d.materials[d.material]; ok {
+		ls.Colors = m.Kd.Vec4(m.Alpha)
+	}
+	for _, f := range fields {
+		v, err := d.parseVertex(f)
+		if err != nil {
+			return err
+		}
+		ls.Points = append(ls.Points, d.positions[v.v])
+	}
+	d.file.LineSets = append(d.file.LineSets, ls)
+	return nil
+}
+
+// parseVertex parses v, v/vt, v//vn or v/vt/vn and resolves negative indices
+func (d *decoder) parseVertex(s string) (vertex, error) {
+
+	parts := strings.Split(s, "/")
+	res := vertex{-1, -1, -1}
+	counts := []int{len(d.positions), len(d.texCoords), len(d.normals)}
+	targets := []*int{&res.v, &res.vt, &res.vn}
+
+	for i, p := range parts {
+		if i > 2 {
+			break
+		}
+		if p == "" {
+			continue
+		}
+		idx, err := strconv.Atoi(p)
+		if err != nil {
+			return res, fmt.Errorf("invalid index %q", s)
+		}
+		if idx < 0 {
+			idx = counts[i] + idx
+		} else {
+			idx--
+		}
+		if idx < 0 || idx >= counts[i] {
+			return res, fmt.Errorf("index %q out of range", s)
+		}
+		*targets[i] = idx
+	}
+	if res.v < 0 {
+		return res, fmt.Errorf("vertex %q has no position", s)
+	}
+	return res, nil
+}
+
+// meshBuilder returns the builder for the current object and material
+func (d *decoder) meshBuilder() *meshBuilder {
+
+	if d.current != nil {
+		return d.current
+	}
+	mb := &meshBuilder{
+		mesh: rex.Mesh{
+			ID:         d.nextID(),
+			Name:       d.name,
+			MaterialID: rex.NotSpecified,
+		},
+		indices: make(map[vertex]uint32),
+	}
+	if m, ok := d.materials[d.material]; ok {
+		mb.mesh.MaterialID = d.materialID(m)
+	}
+	d.meshes = append(d.meshes, mb)
+	d.current = mb
+	return mb
+}
+
+// index returns the REX index of the given OBJ vertex, new vertices are added to the mesh
+func (mb *meshBuilder) index(d *decoder, v vertex) uint32 {
+
+	if idx, ok := mb.indices[v]; ok {
+		return idx
+	}
+	idx := uint32(len(mb.mesh.Coords))
+	mb.indices[v] = idx
+
+	mb.mesh.Coords = append(mb.mesh.Coords, d.positions[v.v])
+	if len(d.colors) == len(d.positions) {
+		mb.mesh.Colors = append(mb.mesh.Colors, d.colors[v.v])
+	}
+	var vt mgl32.Vec2
+	if v.vt >= 0 {
+		vt = d.texCoords[v.vt]
+		mb.hasTexture = true
+	}
+	mb.mesh.TexCoords = append(mb.mesh.TexCoords, vt)
+	var vn mgl32.Vec3
+	if v.vn >= 0 {
+		vn = d.normals[v.vn]
+		mb.hasNormals = true
+	}
+	mb.mesh.Normals = append(mb.mesh.Normals, vn)
+	return idx
+}
+
+// finish adds all meshes to the file. Attributes which are not used by any
+// vertex of a mesh are dropped.
+func (d *decoder) finish() {
+
+	for _, mb := range d.meshes {
+		m := mb.mesh
+		if !mb.hasTexture {
+			m.TexCoords = nil
+		}
+		if !mb.hasNormals {
+			m.Normals = nil
+		}
+		if len(m.Colors) != len(m.Coords) {
+			m.Colors = nil
+		}
+		if len(m.Triangles) > 0 {
+			d.file.Meshes = append(d.file.Meshes, m)
+		}
+	}
+}
+
+// parseFloats parses at least min float values
+func parseFloats(fields []string, min int) ([]float32, error) {
+
+	if len(fields) < min {
+		return nil, fmt.Errorf("expected %d values, got %d", min, len(fields))
+	}
+	values := make([]float32, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = float32(v)
+	}
+	return values, nil
+}
@@ -0,0 +1,248 @@
+package obj
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+const cubeObj = `# cube with separate position, texture and normal indices
+mtllib cube.mtl
+o cube
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+v 0 0 1
+v 1 0 1
+v 1 1 1
+v 0 1 1
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 -1
+vn 0 0 1
+vn 0 -1 0
+vn 1 0 0
+vn 0 1 0
+vn -1 0 0
+usemtl red
+f 1/1/1 4/4/1 3/3/1 2/2/1
+f 5/1/2 6/2/2 7/3/2 8/4/2
+f 1/1/3 2/2/3 6/3/3 5/4/3
+f 2/1/4 3/2/4 7/3/4 6/4/4
+f 3/1/5 4/2/5 8/3/5 7/4/5
+f 4/1/6 1/2/6 5/3/6 8/4/6
+`
+
+const cubeMtl = `newmtl red
+Ka 0.1 0.1 0.1
+Kd 1 0 0
+Ks 0.5
+Ns 10
+d 0.5
+map_Kd -s 1 1 1 textures\red.png
+`
+
+type memFiles map[string]string
+
+func (m memFiles) open(name string) (io.ReadCloser, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	return ioutil.NopCloser(strings.NewReader(data)), nil
+}
+
+func TestDecodeCube(t *testing.T) {
+
+	files := memFiles{"cube.mtl": cubeMtl, "textures/red.png": "png"}
+	f, err := NewDecoderWithOptions(strings.NewReader(cubeObj), DecoderOptions{Open: files.open}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	if len(f.Meshes) != 1 {
+		t.Fatalf("Expected 1 mesh, got %d", len(f.Meshes))
+	}
+	m := f.Meshes[0]
+	if m.Name != "cube" {
+		t.Errorf("Expected name cube, got %s", m.Name)
+	}
+	// 6 quads with own normals -> 24 vertices, 12 triangles
+	if len(m.Coords) != 24 || len(m.Normals) != 24 || len(m.TexCoords) != 24 {
+		t.Errorf("Expected 24 vertices, got %d/%d/%d", len(m.Coords), len(m.Normals), len(m.TexCoords))
+	}
+	if len(m.Triangles) != 12 {
+		t.Errorf("Expected 12 triangles, got %d", len(m.Triangles))
+	}
+	if m.Colors != nil {
+		t.Errorf("Expected no colors")
+	}
+
+	if len(f.Materials) != 1 {
+		t.Fatalf("Expected 1 material, got %d", len(f.Materials))
+	}
+	mat := f.Materials[0]
+	if m.MaterialID != mat.ID {
+		t.Errorf("Mesh references material %d, expected %d", m.MaterialID, mat.ID)
+	}
+	if mat.KdRgb != (mgl32.Vec3{1, 0, 0}) || mat.KsRgb != (mgl32.Vec3{0.5, 0.5, 0.5}) {
+		t.Errorf("Wrong material colors %v %v", mat.KdRgb, mat.KsRgb)
+	}
+	if mat.Ns != 10 || mat.Alpha != 0.5 {
+		t.Errorf("Wrong material parameters ns=%f alpha=%f", mat.Ns, mat.Alpha)
+	}
+
+	if len(f.Images) != 1 {
+		t.Fatalf("Expected 1 image, got %d", len(f.Images))
+	}
+	img := f.Images[0]
+	if mat.KdTextureID != img.ID || mat.KaTextureID != rex.NotSpecified {
+		t.Errorf("Wrong texture references %d %d", mat.KdTextureID, mat.KaTextureID)
+	}
+	if img.Compression != rex.Png || string(img.Data) != "png" {
+		t.Errorf("Wrong image %d %q", img.Compression, img.Data)
+	}
+
+	if issues := rex.Validate(*f); len(issues) > 0 {
+		t.Errorf("Validation failed: %v", issues)
+	}
+
+	// the result must be a valid REX file
+	var buf bytes.Buffer
+	if err := rex.NewEncoder(&buf).Encode(*f); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+}
+
+func TestDecodePolygonAndNegativeIndices(t *testing.T) {
+
+	obj := `v 0 0 0 1 0 0
+v 1 0 0 0 1 0
+v 2 1 0 0 0 1
+v 1 2 0 1 1 1
+v 0 1 0 0 0 0
+f -5 -4 -3 -2 -1
+`
+	f, err := NewDecoder(strings.NewReader(obj)).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.Meshes) != 1 {
+		t.Fatalf("Expected 1 mesh, got %d", len(f.Meshes))
+	}
+	m := f.Meshes[0]
+	if len(m.Triangles) != 3 {
+		t.Errorf("Expected 3 triangles, got %d", len(m.Triangles))
+	}
+	if len(m.Coords) != 5 || len(m.Colors) != 5 {
+		t.Errorf("Expected 5 colored vertices, got %d/%d", len(m.Coords), len(m.Colors))
+	}
+	if m.Normals != nil || m.TexCoords != nil {
+		t.Errorf("Expected no normals and texture coordinates")
+	}
+	if m.Triangles[2] != (rex.Triangle{V0: 0, V1: 3, V2: 4}) {
+		t.Errorf("Wrong triangle %v", m.Triangles[2])
+	}
+	if m.MaterialID != rex.NotSpecified {
+		t.Errorf("Expected no material")
+	}
+}
+
+func TestDecodeConcavePolygon(t *testing.T) {
+
+	// L-shape, a fan from the first corner would create a triangle outside
+	obj := `v 2 1 0
+v 1 1 0
+v 1 2 0
+v 0 2 0
+v 0 0 0
+v 2 0 0
+f 1 2 3 4 5 6
+`
+	f, err := NewDecoder(strings.NewReader(obj)).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	m := f.Meshes[0]
+	if len(m.Triangles) != 4 {
+		t.Fatalf("Expected 4 triangles, got %d", len(m.Triangles))
+	}
+	var area float32
+	for _, tri := range m.Triangles {
+		a, b, c := m.Coords[tri.V0], m.Coords[tri.V1], m.Coords[tri.V2]
+		n := b.Sub(a).Cross(c.Sub(a))
+		if n.Z() <= 0 {
+			t.Errorf("Triangle %v is flipped or degenerated", tri)
+		}
+		area += n.Len() / 2
+	}
+	if area != 3 {
+		t.Errorf("Expected area 3, got %v", area)
+	}
+}
+
+func TestDecodeLines(t *testing.T) {
+
+	obj := `v 0 0 0
+v 1 0 0
+v 1 1 0
+l 1 2 3
+`
+	f, err := NewDecoder(strings.NewReader(obj)).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.Meshes) != 0 || len(f.LineSets) != 1 {
+		t.Fatalf("Expected 1 line set, got %d meshes, %d line sets", len(f.Meshes), len(f.LineSets))
+	}
+	if len(f.LineSets[0].Points) != 3 {
+		t.Errorf("Expected 3 points, got %d", len(f.LineSets[0].Points))
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+
+	tests := []string{
+		"v 0 0",
+		"v 0 0 0\nf 1 2 3",
+		"v 0 0 0\nf 1 1",
+		"v 0 0 0\nv 0 0 0\nv 0 0 0\nf 1/a 2 3",
+	}
+	for _, obj := range tests {
+		if _, err := NewDecoder(strings.NewReader(obj)).Decode(); err == nil {
+			t.Errorf("Expected error for %q", obj)
+		}
+	}
+
+	// missing material libraries are an error if files can be opened
+	_, err := NewDecoderWithOptions(strings.NewReader("mtllib missing.mtl"), DecoderOptions{Open: memFiles{}.open}).Decode()
+	if err == nil {
+		t.Errorf("Expected error for missing material library")
+	}
+}
+
+func TestMapFile(t *testing.T) {
+
+	tests := map[string]string{
+		"texture.png":                          "texture.png",
+		"my texture.png":                       "my texture.png",
+		"-s 1 1 1 textures\\red.png":           "textures/red.png",
+		"-o 0.5 -s 2 -clamp on my texture.jpg": "my texture.jpg",
+		"-mm 0 1 -bm 0.5 12.png":               "12.png",
+		"-s 1 1 1 5":                           "5",
+	}
+	for line, expected := range tests {
+		if name := mapFile(strings.Fields(line)); name != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, line, name)
+		}
+	}
+}
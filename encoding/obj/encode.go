@@ -0,0 +1,273 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// EncoderOptions control the OBJ export
+type EncoderOptions struct {
+	// MaterialLibrary is the name of the MTL file which is referenced by the OBJ file
+	MaterialLibrary string
+	// Create creates the files which are referenced by the OBJ file (material
+	// library and textures). If nil, materials and textures are not exported.
+	Create func(name string) (io.WriteCloser, error)
+}
+
+// Encoder writes a REX file as Wavefront OBJ file. Meshes become faces (incl.
+// normals, texture coordinates and vertex colors), line sets become line
+// elements and point lists become vertices. Materials are written to the
+// material library and JPEG/PNG images are written as texture files.
+// Scene nodes are not applied, all geometries are written in their local
+// coordinate system.
+type Encoder struct {
+	w    io.Writer
+	opts EncoderOptions
+}
+
+// NewEncoder creates a new OBJ encoder w/o material support
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// NewEncoderWithOptions creates a new OBJ encoder with the given options
+func NewEncoderWithOptions(w io.Writer, opts EncoderOptions) *Encoder {
+	return &Encoder{w: w, opts: opts}
+}
+
+// WriteFile writes the REX file as OBJ file with the given name. The material
+// library and the textures are written into the same directory.
+func WriteFile(name string, f rex.File) error {
+
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	dir := filepath.Dir(name)
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	err = NewEncoderWithOptions(out, EncoderOptions{
+		MaterialLibrary: base + ".mtl",
+		Create: func(ref string) (io.WriteCloser, error) {
+			return os.Create(filepath.Join(dir, filepath.FromSlash(ref)))
+		},
+	}).Encode(f)
+	if err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// encoder holds the state while writing
+type encoder struct {
+	w         *bufio.Writer
+	materials map[uint64]string // material names by ID
+	textures  map[uint64]string // texture file names by image ID
+
+	// number of written vertices, texture coordinates and normals
+	v, vt, vn int
+}
+
+// Encode writes the REX file as OBJ
+func (enc *Encoder) Encode(f rex.File) error {
+
+	e := &encoder{
+		w:         bufio.NewWriter(enc.w),
+		materials: make(map[uint64]string),
+		textures:  make(map[uint64]string),
+	}
+
+	if enc.opts.Create != nil && len(f.Materials) > 0 {
+		lib := enc.opts.MaterialLibrary
+		if lib == "" {
+			lib = "materials.mtl"
+		}
+		if err := e.writeTextures(enc.opts.Create, f.Images); err != nil {
+			return err
+		}
+		if err := e.writeMaterialLibrary(enc.opts.Create, lib, f.Materials); err != nil {
+			return err
+		}
+		fmt.Fprintf(e.w, "mtllib %s\n", lib)
+	}
+
+	for _, m := range f.Meshes {
+		e.writeMesh(m)
+	}
+	for _, ls := range f.LineSets {
+		e.writeLineSet(ls)
+	}
+	for _, pl := range f.PointLists {
+		e.writePointList(pl)
+	}
+	return e.w.Flush()
+}
+
+// writeTextures writes all JPEG and PNG images as texture files
+func (e *encoder) writeTextures(create func(string) (io.WriteCloser, error), images []rex.Image) error {
+
+	for _, img := range images {
+		var name string
+		switch img.Compression {
+		case rex.Jpeg:
+			name = fmt.Sprintf("texture_%d.jpg", img.ID)
+		case rex.Png:
+			name = fmt.Sprintf("texture_%d.png", img.ID)
+		default:
+			continue
+		}
+		if err := writeAll(create, name, img.Data); err != nil {
+			return err
+		}
+		e.textures[img.ID] = name
+	}
+	return nil
+}
+
+// writeMaterialLibrary writes the MTL file
+func (e *encoder) writeMaterialLibrary(create func(string) (io.WriteCloser, error), name string, materials []rex.Material) error {
+
+	var sb strings.Builder
+	for _, m := range materials {
+		mtl := fmt.Sprintf("material_%d", m.ID)
+		e.materials[m.ID] = mtl
+
+		fmt.Fprintf(&sb, "newmtl %s\n", mtl)
+		fmt.Fprintf(&sb, "Ka %v %v %v\n", m.KaRgb[0], m.KaRgb[1], m.KaRgb[2])
+		fmt.Fprintf(&sb, "Kd %v %v %v\n", m.KdRgb[0], m.KdRgb[1], m.KdRgb[2])
+		fmt.Fprintf(&sb, "Ks %v %v %v\n", m.KsRgb[0], m.KsRgb[1], m.KsRgb[2])
+		fmt.Fprintf(&sb, "Ns %v\n", m.Ns)
+		fmt.Fprintf(&sb, "d %v\n", m.Alpha)
+		if tex, ok := e.textures[m.KaTextureID]; ok {
+			fmt.Fprintf(&sb, "map_Ka %s\n", tex)
+		}
+		if tex, ok := e.textures[m.KdTextureID]; ok {
+			fmt.Fprintf(&sb, "map_Kd %s\n", tex)
+		}
+		if tex, ok := e.textures[m.KsTextureID]; ok {
+			fmt.Fprintf(&sb, "map_Ks %s\n", tex)
+		}
+		sb.WriteString("\n")
+	}
+	return writeAll(create, name, []byte(sb.String()))
+}
+
+func (e *encoder) writeMesh(m rex.Mesh) {
+
+	name := m.Name
+	if name == "" {
+		name = fmt.Sprintf("mesh_%d", m.ID)
+	}
+	fmt.Fprintf(e.w, "o %s\n", name)
+	if mtl, ok := e.materials[m.MaterialID]; ok {
+		fmt.Fprintf(e.w, "usemtl %s\n", mtl)
+	}
+
+	var colors []mgl32.Vec3
+	if len(m.Colors) == len(m.Coords) {
+		colors = m.Colors
+	}
+	e.writeVertices(m.Coords, colors)
+
+	hasTexture := len(m.TexCoords) == len(m.Coords)
+	if hasTexture {
+		for _, vt := range m.TexCoords {
+			fmt.Fprintf(e.w, "vt %v %v\n", vt[0], vt[1])
+		}
+	}
+	hasNormals := len(m.Normals) == len(m.Coords)
+	if hasNormals {
+		for _, vn := range m.Normals {
+			fmt.Fprintf(e.w, "vn %v %v %v\n", vn[0], vn[1], vn[2])
+		}
+	}
+
+	// REX uses one index for all attributes, therefore the OBJ indices only differ by the offsets
+	corner := func(i uint32) string {
+		v := fmt.Sprint(e.v - len(m.Coords) + int(i) + 1)
+		switch {
+		case hasTexture && hasNormals:
+			return fmt.Sprintf("%s/%d/%d", v, e.vt+int(i)+1, e.vn+int(i)+1)
+		case hasTexture:
+			return fmt.Sprintf("%s/%d", v, e.vt+int(i)+1)
+		case hasNormals:
+			return fmt.Sprintf("%s//%d", v, e.vn+int(i)+1)
+		}
+		return v
+	}
+	for _, t := range m.Triangles {
+		fmt.Fprintf(e.w, "f %s %s %s\n", corner(t.V0), corner(t.V1), corner(t.V2))
+	}
+
+	if hasTexture {
+		e.vt += len(m.TexCoords)
+	}
+	if hasNormals {
+		e.vn += len(m.Normals)
+	}
+}
+
+// writeLineSet writes the line set as polyline, the color is stored as vertex color
+func (e *encoder) writeLineSet(ls rex.LineSet) {
+
+	if len(ls.Points) < 2 {
+		return
+	}
+	fmt.Fprintf(e.w, "o lineset_%d\n", ls.ID)
+	colors := make([]mgl32.Vec3, len(ls.Points))
+	for i := range colors {
+		colors[i] = ls.Colors.Vec3()
+	}
+	e.writeVertices(ls.Points, colors)
+
+	e.w.WriteString("l")
+	for i := range ls.Points {
+		fmt.Fprintf(e.w, " %d", e.v-len(ls.Points)+i+1)
+	}
+	e.w.WriteString("\n")
+}
+
+func (e *encoder) writePointList(pl rex.PointList) {
+
+	fmt.Fprintf(e.w, "o points_%d\n", pl.ID)
+	var colors []mgl32.Vec3
+	if len(pl.Colors) == len(pl.Points) {
+		colors = pl.Colors
+	}
+	e.writeVertices(pl.Points, colors)
+}
+
+// writeVertices writes the positions, colors are optional (nil)
+func (e *encoder) writeVertices(coords, colors []mgl32.Vec3) {
+
+	for i, v := range coords {
+		if colors != nil {
+			c := colors[i]
+			fmt.Fprintf(e.w, "v %v %v %v %v %v %v\n", v[0], v[1], v[2], c[0], c[1], c[2])
+		} else {
+			fmt.Fprintf(e.w, "v %v %v %v\n", v[0], v[1], v[2])
+		}
+	}
+	e.v += len(coords)
+}
+
+func writeAll(create func(string) (io.WriteCloser, error), name string, data []byte) error {
+
+	w, err := create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,112 @@
+package obj
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+
+	files := memFiles{"cube.mtl": cubeMtl, "textures/red.png": "png"}
+	cube, err := NewDecoderWithOptions(strings.NewReader(cubeObj), DecoderOptions{Open: files.open}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	cube.LineSets = append(cube.LineSets, rex.LineSet{
+		ID:     100,
+		Colors: mgl32.Vec4{0, 1, 0, 1},
+		Points: []mgl32.Vec3{{0, 0, 0}, {1, 1, 1}, {2, 0, 0}},
+	})
+	cube.PointLists = append(cube.PointLists, rex.PointList{
+		ID:     101,
+		Points: []mgl32.Vec3{{5, 5, 5}},
+	})
+
+	written := make(map[string]*bytes.Buffer)
+	create := func(name string) (io.WriteCloser, error) {
+		buf := &bytes.Buffer{}
+		written[name] = buf
+		return nopCloser{buf}, nil
+	}
+
+	var obj bytes.Buffer
+	err = NewEncoderWithOptions(&obj, EncoderOptions{MaterialLibrary: "out.mtl", Create: create}).Encode(*cube)
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if _, ok := written["out.mtl"]; !ok {
+		t.Fatalf("Material library not written")
+	}
+	tex := fmt.Sprintf("texture_%d.png", cube.Images[0].ID)
+	if buf, ok := written[tex]; !ok || buf.String() != "png" {
+		t.Fatalf("Texture %s not written: %v", tex, written)
+	}
+	if !strings.Contains(written["out.mtl"].String(), "map_Kd "+tex) {
+		t.Errorf("Texture is not referenced:\n%s", written["out.mtl"])
+	}
+
+	// read it again
+	read := memFiles{}
+	for name, buf := range written {
+		read[name] = buf.String()
+	}
+	f, err := NewDecoderWithOptions(strings.NewReader(obj.String()), DecoderOptions{Open: read.open}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.Meshes) != 1 || len(f.Materials) != 1 || len(f.Images) != 1 || len(f.LineSets) != 1 {
+		t.Fatalf("Expected 1 mesh, material, image and line set, got %d/%d/%d/%d",
+			len(f.Meshes), len(f.Materials), len(f.Images), len(f.LineSets))
+	}
+	m, orig := f.Meshes[0], cube.Meshes[0]
+	if m.Name != orig.Name || len(m.Coords) != len(orig.Coords) || len(m.Triangles) != len(orig.Triangles) {
+		t.Errorf("Mesh differs: %s %d %d", m.Name, len(m.Coords), len(m.Triangles))
+	}
+	for i := range orig.Coords {
+		if m.Coords[i] != orig.Coords[i] || m.Normals[i] != orig.Normals[i] || m.TexCoords[i] != orig.TexCoords[i] {
+			t.Fatalf("Vertex %d differs", i)
+		}
+	}
+	if f.Materials[0].KdRgb != cube.Materials[0].KdRgb || f.Materials[0].Alpha != cube.Materials[0].Alpha {
+		t.Errorf("Material differs: %v", f.Materials[0])
+	}
+	if len(f.LineSets[0].Points) != 3 {
+		t.Errorf("Expected 3 line points, got %d", len(f.LineSets[0].Points))
+	}
+	if !strings.Contains(obj.String(), "o points_101\nv 5 5 5\n") {
+		t.Errorf("Point list not written")
+	}
+}
+
+func TestEncodeWithoutMaterials(t *testing.T) {
+
+	f := rex.File{Meshes: []rex.Mesh{{
+		ID:         1,
+		Coords:     []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		Triangles:  []rex.Triangle{{V0: 0, V1: 1, V2: 2}},
+		MaterialID: 2,
+	}}, Materials: []rex.Material{rex.NewMaterial(2)}}
+
+	var obj bytes.Buffer
+	if err := NewEncoder(&obj).Encode(f); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	expected := "o mesh_1\nv 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n"
+	if obj.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, obj.String())
+	}
+}
@@ -0,0 +1,228 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// material is a material of a MTL file
+type material struct {
+	Name  string
+	Ka    mgl32.Vec3
+	Kd    mgl32.Vec3
+	Ks    mgl32.Vec3
+	Ns    float32
+	Alpha float32
+	MapKa string
+	MapKd string
+	MapKs string
+
+	id uint64 // ID of the REX material, 0 if not created yet
+}
+
+// newMaterial returns a material with the defaults of a REX material
+func newMaterial(name string) *material {
+	def := rex.NewMaterial(0)
+	return &material{
+		Name:  name,
+		Ka:    def.KaRgb,
+		Kd:    def.KdRgb,
+		Ks:    def.KsRgb,
+		Ns:    def.Ns,
+		Alpha: def.Alpha,
+	}
+}
+
+// loadMaterialLibrary reads all materials of the given MTL file
+func (d *decoder) loadMaterialLibrary(name string) error {
+
+	if d.opts.Open == nil {
+		return nil
+	}
+	f, err := d.opts.Open(name)
+	if err != nil {
+		return fmt.Errorf("cannot open material library %s: %v", name, err)
+	}
+	defer f.Close()
+
+	materials, err := readMaterials(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	for _, m := range materials {
+		d.materials[m.Name] = m
+	}
+	return nil
+}
+
+// readMaterials parses a MTL file
+func readMaterials(r io.Reader) ([]*material, error) {
+
+	var materials []*material
+	var current *material
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "newmtl" {
+			current = newMaterial(strings.Join(fields[1:], " "))
+			materials = append(materials, current)
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "Ka":
+			current.Ka, err = parseColor(fields[1:])
+		case "Kd":
+			current.Kd, err = parseColor(fields[1:])
+		case "Ks":
+			current.Ks, err = parseColor(fields[1:])
+		case "Ns":
+			current.Ns, err = parseFloat(fields[1:])
+		case "d":
+			current.Alpha, err = parseFloat(fields[1:])
+		case "Tr":
+			var tr float32
+			tr, err = parseFloat(fields[1:])
+			current.Alpha = 1 - tr
+		case "map_Ka":
+			current.MapKa = mapFile(fields[1:])
+		case "map_Kd":
+			current.MapKd = mapFile(fields[1:])
+		case "map_Ks":
+			current.MapKs = mapFile(fields[1:])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	return materials, scanner.Err()
+}
+
+// materialID returns the ID of the REX material, which is created on first use
+func (d *decoder) materialID(m *material) uint64 {
+
+	if m.id != 0 {
+		return m.id
+	}
+	m.id = d.nextID()
+
+	mat := rex.NewMaterial(m.id)
+	mat.KaRgb = m.Ka
+	mat.KdRgb = m.Kd
+	mat.KsRgb = m.Ks
+	mat.Ns = m.Ns
+	mat.Alpha = m.Alpha
+	mat.KaTextureID = d.textureID(m.MapKa)
+	mat.KdTextureID = d.textureID(m.MapKd)
+	mat.KsTextureID = d.textureID(m.MapKs)
+	d.file.Materials = append(d.file.Materials, mat)
+	return m.id
+}
+
+// textureID loads the texture as image block and returns its ID. Textures
+// which cannot be loaded or are neither JPEG nor PNG are ignored.
+func (d *decoder) textureID(name string) uint64 {
+
+	if name == "" || d.opts.Open == nil {
+		return rex.NotSpecified
+	}
+	if id, ok := d.textures[name]; ok {
+		return id
+	}
+
+	var compression uint32
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jpg", ".jpeg":
+		compression = rex.Jpeg
+	case ".png":
+		compression = rex.Png
+	default:
+		return rex.NotSpecified
+	}
+
+	d.textures[name] = rex.NotSpecified
+	f, err := d.opts.Open(name)
+	if err != nil {
+		return rex.NotSpecified
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return rex.NotSpecified
+	}
+
+	img := rex.Image{ID: d.nextID(), Compression: compression, Data: data}
+	d.file.Images = append(d.file.Images, img)
+	d.textures[name] = img.ID
+	return img.ID
+}
+
+func parseColor(fields []string) (mgl32.Vec3, error) {
+	values, err := parseFloats(fields, 1)
+	if err != nil {
+		return mgl32.Vec3{}, err
+	}
+	// a single value is used for all channels
+	if len(values) < 3 {
+		return mgl32.Vec3{values[0], values[0], values[0]}, nil
+	}
+	return mgl32.Vec3{values[0], values[1], values[2]}, nil
+}
+
+func parseFloat(fields []string) (float32, error) {
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("missing value")
+	}
+	v, err := strconv.ParseFloat(fields[0], 32)
+	return float32(v), err
+}
+
+// mapOptions contains the number of values of the texture map options, -o,
+// -s and -t have up to 3 values
+var mapOptions = map[string]int{
+	"-blendu": 1, "-blendv": 1, "-boost": 1, "-mm": 2, "-o": 3, "-s": 3, "-t": 3,
+	"-texres": 1, "-clamp": 1, "-bm": 1, "-imfchan": 1, "-type": 1, "-cc": 1,
+}
+
+// mapFile returns the file name of a texture map statement, options like -s or
+// -o are skipped. The file name may contain spaces.
+func mapFile(fields []string) string {
+
+	for len(fields) > 0 {
+		n, ok := mapOptions[fields[0]]
+		if !ok {
+			break
+		}
+		fields = fields[1:]
+		for i := 0; i < n && len(fields) > 1; i++ {
+			// optional values of -o, -s and -t are numbers
+			if _, err := strconv.ParseFloat(fields[0], 64); i > 0 && err != nil {
+				break
+			}
+			fields = fields[1:]
+		}
+	}
+	return strings.Replace(strings.Join(fields, " "), "\\", "/", -1)
+}
@@ -0,0 +1,418 @@
+package ply
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// DefaultChunkSize is the default maximum number of points of a point list
+const DefaultChunkSize = 1000000
+
+// DecoderOptions control the PLY import
+type DecoderOptions struct {
+	// ChunkSize is the maximum number of points of a point list, larger point
+	// clouds are split into several point lists. If 0 the DefaultChunkSize is used.
+	ChunkSize int
+}
+
+// Decoder reads a PLY file (ASCII, binary little or big endian). Files w/o
+// faces are converted into point lists, files with faces into one mesh.
+// The colors are normalized to 0..1. Point clouds are read in chunks,
+// therefore arbitrary large files can be streamed with Next.
+type Decoder struct {
+	br     *bufio.Reader
+	opts   DecoderOptions
+	header *Header
+	values valueReader
+
+	element   int // index of the current element
+	remaining int // number of remaining entries of the current element
+	id        uint64
+	done      bool
+}
+
+// NewDecoder creates a new PLY decoder
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, DecoderOptions{})
+}
+
+// NewDecoderWithOptions creates a new PLY decoder with the given options
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	return &Decoder{br: bufio.NewReaderSize(r, 64*1024), opts: opts}
+}
+
+// ReadFile reads the given PLY file
+func ReadFile(name string) (*rex.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewDecoder(f).Decode()
+}
+
+// Header reads the PLY header, it is called automatically by Next
+func (dec *Decoder) Header() (*Header, error) {
+
+	if dec.header != nil {
+		return dec.header, nil
+	}
+	h, err := readHeader(dec.br)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := h.Element("vertex"); !ok {
+		return nil, fmt.Errorf("PLY file has no vertex element")
+	}
+	dec.header = h
+	if h.Format == ASCII {
+		dec.values = &asciiReader{r: dec.br}
+	} else {
+		dec.values = &binaryReader{r: dec.br, order: h.Format.byteOrder()}
+	}
+	dec.element = -1
+	return h, nil
+}
+
+// Next returns the next block, which is either a point list with at most
+// ChunkSize points or the mesh. At the end io.EOF is returned.
+func (dec *Decoder) Next() (rex.Block, error) {
+
+	h, err := dec.Header()
+	if err != nil {
+		return nil, err
+	}
+	if dec.done {
+		return nil, io.EOF
+	}
+	if faces, ok := h.Element("face"); ok && faces.Count > 0 {
+		dec.done = true
+		return dec.readMesh()
+	}
+
+	// skip all elements up to the vertices
+	for dec.remaining == 0 {
+		if dec.element >= 0 && h.Elements[dec.element].Name == "vertex" {
+			dec.done = true
+			return nil, io.EOF
+		}
+		if err := dec.nextElement(); err != nil {
+			return nil, err
+		}
+		if h.Elements[dec.element].Name != "vertex" {
+			if err := dec.skipElement(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return dec.readPointList()
+}
+
+// Decode reads the complete PLY file
+func (dec *Decoder) Decode() (*rex.File, error) {
+
+	f := &rex.File{}
+	for {
+		b, err := dec.Next()
+		if err == io.EOF {
+			return f, nil
+		} else if err != nil {
+			return nil, err
+		}
+		switch b := b.(type) {
+		case *rex.PointList:
+			f.PointLists = append(f.PointLists, *b)
+		case *rex.Mesh:
+			f.Meshes = append(f.Meshes, *b)
+		}
+	}
+}
+
+// nextElement moves to the next element
+func (dec *Decoder) nextElement() error {
+	dec.element++
+	if dec.element >= len(dec.header.Elements) {
+		return fmt.Errorf("PLY file has no further element")
+	}
+	dec.remaining = dec.header.Elements[dec.element].Count
+	return nil
+}
+
+// skipElement reads all remaining entries of the current element
+func (dec *Decoder) skipElement() error {
+	e := dec.header.Elements[dec.element]
+	values := make([]float64, len(e.Properties))
+	for ; dec.remaining > 0; dec.remaining-- {
+		if err := dec.readEntry(e, values, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readEntry reads all properties of one entry, list properties are replaced by
+// their length. The vertex indices of a face are returned in list (if not nil).
+func (dec *Decoder) readEntry(e Element, values []float64, list *[]uint32) error {
+
+	for i, p := range e.Properties {
+		if p.CountType == "" {
+			v, err := dec.values.read(p.Type)
+			if err != nil {
+				return fmt.Errorf("Reading %s failed: %w", e.Name, err)
+			}
+			values[i] = v
+			continue
+		}
+
+		n, err := dec.values.read(p.CountType)
+		if err != nil {
+			return fmt.Errorf("Reading %s failed: %w", e.Name, err)
+		}
+		if n < 0 {
+			return fmt.Errorf("Invalid list size %v", n)
+		}
+		values[i] = n
+		if list != nil {
+			*list = (*list)[:0]
+		}
+		for j := 0; j < int(n); j++ {
+			v, err := dec.values.read(p.Type)
+			if err != nil {
+				return fmt.Errorf("Reading %s failed: %w", e.Name, err)
+			}
+			if list != nil && (p.Name == "vertex_indices" || p.Name == "vertex_index") {
+				*list = append(*list, uint32(v))
+			}
+		}
+	}
+	return nil
+}
+
+// vertexLayout contains the property indices of the vertex attributes, -1 if not available
+type vertexLayout struct {
+	pos        [3]int
+	normal     [3]int
+	color      [3]int
+	texCoord   [2]int
+	colorScale float64
+}
+
+func newVertexLayout(e Element) (vertexLayout, error) {
+
+	l := vertexLayout{
+		pos:      [3]int{e.index("x"), e.index("y"), e.index("z")},
+		normal:   [3]int{e.index("nx"), e.index("ny"), e.index("nz")},
+		color:    [3]int{e.index("red", "r", "diffuse_red"), e.index("green", "g", "diffuse_green"), e.index("blue", "b", "diffuse_blue")},
+		texCoord: [2]int{e.index("s", "u", "texture_u"), e.index("t", "v", "texture_v")},
+	}
+	if l.pos[0] < 0 || l.pos[1] < 0 || l.pos[2] < 0 {
+		return l, fmt.Errorf("PLY vertices have no x, y and z properties")
+	}
+	// colors are normalized depending on their type
+	if l.hasColors() {
+		switch e.Properties[l.color[0]].Type {
+		case "float", "float32", "double", "float64":
+			l.colorScale = 1
+		case "ushort", "uint16":
+			l.colorScale = 1.0 / 65535
+		default:
+			l.colorScale = 1.0 / 255
+		}
+	}
+	return l, nil
+}
+
+func (l vertexLayout) hasNormals() bool {
+	return l.normal[0] >= 0 && l.normal[1] >= 0 && l.normal[2] >= 0
+}
+
+func (l vertexLayout) hasColors() bool {
+	return l.color[0] >= 0 && l.color[1] >= 0 && l.color[2] >= 0
+}
+
+func (l vertexLayout) hasTexCoords() bool {
+	return l.texCoord[0] >= 0 && l.texCoord[1] >= 0
+}
+
+func vec3(values []float64, idx [3]int, scale float64) mgl32.Vec3 {
+	return mgl32.Vec3{float32(values[idx[0]] * scale), float32(values[idx[1]] * scale), float32(values[idx[2]] * scale)}
+}
+
+// readPointList reads the next chunk of the vertices
+func (dec *Decoder) readPointList() (*rex.PointList, error) {
+
+	e := dec.header.Elements[dec.element]
+	layout, err := newVertexLayout(e)
+	if err != nil {
+		return nil, err
+	}
+
+	n := dec.remaining
+	if n > dec.opts.ChunkSize {
+		n = dec.opts.ChunkSize
+	}
+	dec.id++
+	pl := &rex.PointList{ID: dec.id, Points: make([]mgl32.Vec3, n)}
+	if layout.hasColors() {
+		pl.Colors = make([]mgl32.Vec3, n)
+	}
+
+	values := make([]float64, len(e.Properties))
+	for i := 0; i < n; i++ {
+		if err := dec.readEntry(e, values, nil); err != nil {
+			return nil, err
+		}
+		dec.remaining--
+		pl.Points[i] = vec3(values, layout.pos, 1)
+		if pl.Colors != nil {
+			pl.Colors[i] = vec3(values, layout.color, layout.colorScale)
+		}
+	}
+	return pl, nil
+}
+
+// readMesh reads all elements and converts the vertices and faces into a mesh.
+// Polygons are triangulated as fans.
+func (dec *Decoder) readMesh() (*rex.Mesh, error) {
+
+	dec.id++
+	m := &rex.Mesh{ID: dec.id, MaterialID: rex.NotSpecified}
+	var layout vertexLayout
+	var indices []uint32
+
+	for dec.element+1 < len(dec.header.Elements) {
+		if err := dec.nextElement(); err != nil {
+			return nil, err
+		}
+		e := dec.header.Elements[dec.element]
+		values := make([]float64, len(e.Properties))
+
+		switch e.Name {
+		case "vertex":
+			var err error
+			if layout, err = newVertexLayout(e); err != nil {
+				return nil, err
+			}
+			for ; dec.remaining > 0; dec.remaining-- {
+				if err := dec.readEntry(e, values, nil); err != nil {
+					return nil, err
+				}
+				m.Coords = append(m.Coords, vec3(values, layout.pos, 1))
+				if layout.hasNormals() {
+					m.Normals = append(m.Normals, vec3(values, layout.normal, 1))
+				}
+				if layout.hasColors() {
+					m.Colors = append(m.Colors, vec3(values, layout.color, layout.colorScale))
+				}
+				if layout.hasTexCoords() {
+					m.TexCoords = append(m.TexCoords, mgl32.Vec2{float32(values[layout.texCoord[0]]), float32(values[layout.texCoord[1]])})
+				}
+			}
+		case "face":
+			if e.index("vertex_indices", "vertex_index") < 0 {
+				return nil, fmt.Errorf("PLY faces have no vertex indices")
+			}
+			for ; dec.remaining > 0; dec.remaining-- {
+				if err := dec.readEntry(e, values, &indices); err != nil {
+					return nil, err
+				}
+				for i := 1; i+1 < len(indices); i++ {
+					m.Triangles = append(m.Triangles, rex.Triangle{V0: indices[0], V1: indices[i], V2: indices[i+1]})
+				}
+			}
+		default:
+			if err := dec.skipElement(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, t := range m.Triangles {
+		if int(t.V0) >= len(m.Coords) || int(t.V1) >= len(m.Coords) || int(t.V2) >= len(m.Coords) {
+			return nil, fmt.Errorf("Face index out of range")
+		}
+	}
+	return m, nil
+}
+
+// valueReader reads single values of the given type
+type valueReader interface {
+	read(typ string) (float64, error)
+}
+
+type binaryReader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	buf   [8]byte
+}
+
+func (br *binaryReader) read(typ string) (float64, error) {
+
+	b := br.buf[:typeSizes[typ]]
+	if _, err := io.ReadFull(br.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	switch typ {
+	case "char", "int8":
+		return float64(int8(b[0])), nil
+	case "uchar", "uint8":
+		return float64(b[0]), nil
+	case "short", "int16":
+		return float64(int16(br.order.Uint16(b))), nil
+	case "ushort", "uint16":
+		return float64(br.order.Uint16(b)), nil
+	case "int", "int32":
+		return float64(int32(br.order.Uint32(b))), nil
+	case "uint", "uint32":
+		return float64(br.order.Uint32(b)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(br.order.Uint32(b))), nil
+	case "double", "float64":
+		return math.Float64frombits(br.order.Uint64(b)), nil
+	}
+	return 0, fmt.Errorf("Invalid type %s", typ)
+}
+
+type asciiReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// read parses the next whitespace separated value
+func (ar *asciiReader) read(typ string) (float64, error) {
+
+	ar.buf = ar.buf[:0]
+	for {
+		c, err := ar.r.ReadByte()
+		if err == io.EOF && len(ar.buf) > 0 {
+			break
+		} else if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return 0, err
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			if len(ar.buf) > 0 {
+				break
+			}
+			continue
+		}
+		ar.buf = append(ar.buf, c)
+	}
+	return strconv.ParseFloat(string(ar.buf), 64)
+}
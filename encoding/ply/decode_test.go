@@ -0,0 +1,165 @@
+package ply
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+const asciiPoints = `ply
+format ascii 1.0
+comment scanner output
+element vertex 3
+property float x
+property float y
+property float z
+property uchar red
+property uchar green
+property uchar blue
+end_header
+0 0 0 255 0 0
+1 0 0 0 255 0
+1.5 2 -3 0 0 51
+`
+
+func TestDecodeASCIIPoints(t *testing.T) {
+
+	dec := NewDecoder(strings.NewReader(asciiPoints))
+	h, err := dec.Header()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if h.Format != ASCII || len(h.Comments) != 1 || h.Comments[0] != "scanner output" {
+		t.Errorf("Invalid header %+v", h)
+	}
+
+	f, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.PointLists) != 1 || len(f.Meshes) != 0 {
+		t.Fatalf("Expected 1 point list, got %d", len(f.PointLists))
+	}
+	pl := f.PointLists[0]
+	if len(pl.Points) != 3 || len(pl.Colors) != 3 {
+		t.Fatalf("Expected 3 colored points, got %d/%d", len(pl.Points), len(pl.Colors))
+	}
+	if pl.Points[2] != (mgl32.Vec3{1.5, 2, -3}) {
+		t.Errorf("Invalid point %v", pl.Points[2])
+	}
+	if pl.Colors[0] != (mgl32.Vec3{1, 0, 0}) || pl.Colors[2] != (mgl32.Vec3{0, 0, 0.2}) {
+		t.Errorf("Invalid colors %v", pl.Colors)
+	}
+}
+
+// binaryPoints creates a big endian point cloud with 16 bit colors and an additional element
+func binaryPoints(n int) []byte {
+
+	var buf bytes.Buffer
+	buf.WriteString("ply\nformat binary_big_endian 1.0\n")
+	buf.WriteString("element camera 1\nproperty double position\n")
+	buf.WriteString("element vertex " + strconv.Itoa(n) + "\n")
+	buf.WriteString("property float x\nproperty float y\nproperty float z\n")
+	buf.WriteString("property ushort red\nproperty ushort green\nproperty ushort blue\n")
+	buf.WriteString("property list uchar int extra\n")
+	buf.WriteString("end_header\n")
+	binary.Write(&buf, binary.BigEndian, float64(42))
+	for i := 0; i < n; i++ {
+		binary.Write(&buf, binary.BigEndian, []float32{float32(i), 0, 1})
+		binary.Write(&buf, binary.BigEndian, []uint16{65535, 0, 0})
+		binary.Write(&buf, binary.BigEndian, uint8(2))
+		binary.Write(&buf, binary.BigEndian, []int32{1, 2})
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeBinaryChunks(t *testing.T) {
+
+	dec := NewDecoderWithOptions(bytes.NewReader(binaryPoints(25)), DecoderOptions{ChunkSize: 10})
+	var sizes []int
+	for {
+		b, err := dec.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+		pl := b.(*rex.PointList)
+		sizes = append(sizes, len(pl.Points))
+		if pl.Colors[0] != (mgl32.Vec3{1, 0, 0}) {
+			t.Errorf("Invalid color %v", pl.Colors[0])
+		}
+		if pl.Points[len(pl.Points)-1][0] != float32(len(sizes)-1)*10+float32(len(pl.Points)-1) {
+			t.Errorf("Invalid point %v", pl.Points[len(pl.Points)-1])
+		}
+	}
+	if len(sizes) != 3 || sizes[0] != 10 || sizes[2] != 5 {
+		t.Errorf("Expected chunks of 10, 10 and 5 points, got %v", sizes)
+	}
+}
+
+const asciiMesh = `ply
+format ascii 1.0
+element vertex 4
+property float x
+property float y
+property float z
+property float nx
+property float ny
+property float nz
+element face 1
+property list uchar int vertex_indices
+property uchar flags
+element edge 1
+property int vertex1
+property int vertex2
+end_header
+0 0 0 0 0 1
+1 0 0 0 0 1
+1 1 0 0 0 1
+0 1 0 0 0 1
+4 0 1 2 3 7
+0 1
+`
+
+func TestDecodeMesh(t *testing.T) {
+
+	f, err := NewDecoder(strings.NewReader(asciiMesh)).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.Meshes) != 1 || len(f.PointLists) != 0 {
+		t.Fatalf("Expected 1 mesh, got %d", len(f.Meshes))
+	}
+	m := f.Meshes[0]
+	if len(m.Coords) != 4 || len(m.Normals) != 4 || m.Colors != nil {
+		t.Errorf("Invalid vertices %d/%d", len(m.Coords), len(m.Normals))
+	}
+	if len(m.Triangles) != 2 || m.Triangles[1] != (rex.Triangle{V0: 0, V1: 2, V2: 3}) {
+		t.Errorf("Invalid triangles %v", m.Triangles)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+
+	tests := []string{
+		"obj\n",
+		"ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nend_header\n0\n",
+		"ply\nformat ascii 2.0 x\nend_header\n",
+		"ply\nformat binary_middle_endian 1.0\nend_header\n",
+		"ply\nformat ascii 1.0\nelement vertex 1\nproperty float128 x\nend_header\n",
+		asciiPoints[:len(asciiPoints)-10],
+		strings.Replace(asciiMesh, "4 0 1 2 3", "4 0 1 2 9", 1),
+	}
+	for _, data := range tests {
+		if _, err := NewDecoder(strings.NewReader(data)).Decode(); err == nil {
+			t.Errorf("Expected error for %q", data)
+		}
+	}
+}
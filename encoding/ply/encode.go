@@ -0,0 +1,313 @@
+package ply
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// EncoderOptions control the PLY export
+type EncoderOptions struct {
+	Format Format
+}
+
+// Encoder writes REX point lists and meshes as PLY file. Colors are written
+// as uchar values, normals and texture coordinates as float values.
+type Encoder struct {
+	w    io.Writer
+	opts EncoderOptions
+}
+
+// NewEncoder creates a new PLY encoder which writes binary little endian files
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, opts: EncoderOptions{Format: BinaryLittleEndian}}
+}
+
+// NewEncoderWithOptions creates a new PLY encoder with the given options
+func NewEncoderWithOptions(w io.Writer, opts EncoderOptions) *Encoder {
+	return &Encoder{w: w, opts: opts}
+}
+
+// WriteFile writes the REX file as binary PLY file. If the file contains
+// meshes, all meshes are merged, otherwise all point lists are written.
+func WriteFile(name string, f rex.File) error {
+
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	enc := NewEncoder(out)
+	if len(f.Meshes) > 0 {
+		err = enc.EncodeMesh(mergeMeshes(f.Meshes))
+	} else {
+		err = enc.EncodePointLists(f.PointLists)
+	}
+	if err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// mergeMeshes combines all meshes into one mesh, attributes are only kept if all meshes have them
+func mergeMeshes(meshes []rex.Mesh) rex.Mesh {
+
+	if len(meshes) == 1 {
+		return meshes[0]
+	}
+	normals, colors, texCoords := true, true, true
+	for _, m := range meshes {
+		normals = normals && len(m.Normals) == len(m.Coords)
+		colors = colors && len(m.Colors) == len(m.Coords)
+		texCoords = texCoords && len(m.TexCoords) == len(m.Coords)
+	}
+
+	var res rex.Mesh
+	for _, m := range meshes {
+		offset := uint32(len(res.Coords))
+		res.Coords = append(res.Coords, m.Coords...)
+		if normals {
+			res.Normals = append(res.Normals, m.Normals...)
+		}
+		if colors {
+			res.Colors = append(res.Colors, m.Colors...)
+		}
+		if texCoords {
+			res.TexCoords = append(res.TexCoords, m.TexCoords...)
+		}
+		for _, t := range m.Triangles {
+			res.Triangles = append(res.Triangles, rex.Triangle{V0: t.V0 + offset, V1: t.V1 + offset, V2: t.V2 + offset})
+		}
+	}
+	return res
+}
+
+// EncodeMesh writes the mesh with its vertex attributes and faces
+func (enc *Encoder) EncodeMesh(m rex.Mesh) error {
+
+	vertex := Element{Name: "vertex", Count: len(m.Coords), Properties: xyz}
+	hasNormals := len(m.Normals) == len(m.Coords) && len(m.Normals) > 0
+	hasColors := len(m.Colors) == len(m.Coords) && len(m.Colors) > 0
+	hasTexCoords := len(m.TexCoords) == len(m.Coords) && len(m.TexCoords) > 0
+	if hasNormals {
+		vertex.Properties = append(vertex.Properties, normals...)
+	}
+	if hasColors {
+		vertex.Properties = append(vertex.Properties, rgb...)
+	}
+	if hasTexCoords {
+		vertex.Properties = append(vertex.Properties, st...)
+	}
+	face := Element{Name: "face", Count: len(m.Triangles), Properties: []Property{
+		{Name: "vertex_indices", Type: "uint", CountType: "uchar"},
+	}}
+
+	w := newValueWriter(enc.w, enc.opts.Format)
+	if err := writeHeader(w.w, Header{Format: enc.opts.Format, Elements: []Element{vertex, face}}); err != nil {
+		return err
+	}
+	for i, c := range m.Coords {
+		w.vec3(c)
+		if hasNormals {
+			w.vec3(m.Normals[i])
+		}
+		if hasColors {
+			w.color(m.Colors[i])
+		}
+		if hasTexCoords {
+			w.float(m.TexCoords[i][0])
+			w.float(m.TexCoords[i][1])
+		}
+		w.endLine()
+	}
+	for _, t := range m.Triangles {
+		w.uchar(3)
+		w.uint(t.V0)
+		w.uint(t.V1)
+		w.uint(t.V2)
+		w.endLine()
+	}
+	return w.flush()
+}
+
+// EncodePointList writes the point list
+func (enc *Encoder) EncodePointList(pl rex.PointList) error {
+	return enc.EncodePointLists([]rex.PointList{pl})
+}
+
+// EncodePointLists writes all point lists as one point cloud. Colors are only
+// written if all point lists have colors.
+func (enc *Encoder) EncodePointLists(pls []rex.PointList) error {
+
+	count := 0
+	colors := len(pls) > 0
+	for _, pl := range pls {
+		count += len(pl.Points)
+		colors = colors && len(pl.Colors) == len(pl.Points)
+	}
+	pw, err := enc.NewPointWriter(count, colors)
+	if err != nil {
+		return err
+	}
+	for _, pl := range pls {
+		c := pl.Colors
+		if !colors {
+			c = nil
+		}
+		if err := pw.Write(pl.Points, c); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+// PointWriter writes a point cloud in several steps, therefore the points
+// must not be kept in memory. The number of points is required upfront.
+type PointWriter struct {
+	w         *valueWriter
+	count     int
+	remaining int
+	colors    bool
+}
+
+// NewPointWriter writes the header of a point cloud with the given number of
+// points. If colors is true, every point requires a color.
+func (enc *Encoder) NewPointWriter(count int, colors bool) (*PointWriter, error) {
+
+	vertex := Element{Name: "vertex", Count: count, Properties: xyz}
+	if colors {
+		vertex.Properties = append(vertex.Properties, rgb...)
+	}
+	w := newValueWriter(enc.w, enc.opts.Format)
+	if err := writeHeader(w.w, Header{Format: enc.opts.Format, Elements: []Element{vertex}}); err != nil {
+		return nil, err
+	}
+	return &PointWriter{w: w, count: count, remaining: count, colors: colors}, nil
+}
+
+// Write writes the next points, the colors are ignored if the point writer has no colors
+func (pw *PointWriter) Write(points, colors []mgl32.Vec3) error {
+
+	if len(points) > pw.remaining {
+		return fmt.Errorf("PLY point count %d exceeded", pw.count)
+	}
+	if pw.colors && len(colors) != len(points) {
+		return fmt.Errorf("Expected %d colors, got %d", len(points), len(colors))
+	}
+	for i, p := range points {
+		pw.w.vec3(p)
+		if pw.colors {
+			pw.w.color(colors[i])
+		}
+		pw.w.endLine()
+	}
+	pw.remaining -= len(points)
+	return pw.w.err
+}
+
+// Close flushes the data and checks that all points are written
+func (pw *PointWriter) Close() error {
+	if err := pw.w.flush(); err != nil {
+		return err
+	}
+	if pw.remaining != 0 {
+		return fmt.Errorf("PLY point count mismatch, %d points missing", pw.remaining)
+	}
+	return nil
+}
+
+var (
+	xyz     = []Property{{Name: "x", Type: "float"}, {Name: "y", Type: "float"}, {Name: "z", Type: "float"}}
+	normals = []Property{{Name: "nx", Type: "float"}, {Name: "ny", Type: "float"}, {Name: "nz", Type: "float"}}
+	rgb     = []Property{{Name: "red", Type: "uchar"}, {Name: "green", Type: "uchar"}, {Name: "blue", Type: "uchar"}}
+	st      = []Property{{Name: "s", Type: "float"}, {Name: "t", Type: "float"}}
+)
+
+// valueWriter writes single values in the given format, the first error is kept
+type valueWriter struct {
+	w      *bufio.Writer
+	format Format
+	order  binary.ByteOrder
+	buf    [4]byte
+	first  bool // first value of the line (ASCII)
+	err    error
+}
+
+func newValueWriter(w io.Writer, format Format) *valueWriter {
+	return &valueWriter{w: bufio.NewWriterSize(w, 64*1024), format: format, order: format.byteOrder(), first: true}
+}
+
+func (vw *valueWriter) write(b []byte) {
+	if vw.err == nil {
+		_, vw.err = vw.w.Write(b)
+	}
+}
+
+func (vw *valueWriter) text(s string) {
+	if !vw.first {
+		s = " " + s
+	}
+	vw.first = false
+	vw.write([]byte(s))
+}
+
+func (vw *valueWriter) float(v float32) {
+	if vw.format == ASCII {
+		vw.text(fmt.Sprint(v))
+		return
+	}
+	vw.order.PutUint32(vw.buf[:], math.Float32bits(v))
+	vw.write(vw.buf[:4])
+}
+
+func (vw *valueWriter) uint(v uint32) {
+	if vw.format == ASCII {
+		vw.text(fmt.Sprint(v))
+		return
+	}
+	vw.order.PutUint32(vw.buf[:], v)
+	vw.write(vw.buf[:4])
+}
+
+func (vw *valueWriter) uchar(v uint8) {
+	if vw.format == ASCII {
+		vw.text(fmt.Sprint(v))
+		return
+	}
+	vw.buf[0] = v
+	vw.write(vw.buf[:1])
+}
+
+func (vw *valueWriter) vec3(v mgl32.Vec3) {
+	vw.float(v[0])
+	vw.float(v[1])
+	vw.float(v[2])
+}
+
+// color writes the color (0..1) as uchar values
+func (vw *valueWriter) color(c mgl32.Vec3) {
+	for i := 0; i < 3; i++ {
+		vw.uchar(uint8(mgl32.Clamp(c[i], 0, 1)*255 + 0.5))
+	}
+}
+
+func (vw *valueWriter) endLine() {
+	if vw.format == ASCII {
+		vw.write([]byte("\n"))
+		vw.first = true
+	}
+}
+
+func (vw *valueWriter) flush() error {
+	if vw.err != nil {
+		return vw.err
+	}
+	return vw.w.Flush()
+}
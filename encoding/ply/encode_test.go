@@ -0,0 +1,122 @@
+package ply
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+var formats = []Format{ASCII, BinaryLittleEndian, BinaryBigEndian}
+
+func TestEncodeMeshRoundTrip(t *testing.T) {
+
+	m := rex.Mesh{
+		Coords:    []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0.5}},
+		Normals:   []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+		Colors:    []mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		TexCoords: []mgl32.Vec2{{0, 0}, {1, 0}, {1, 1}},
+		Triangles: []rex.Triangle{{V0: 0, V1: 1, V2: 2}},
+	}
+
+	for _, format := range formats {
+		var buf bytes.Buffer
+		if err := NewEncoderWithOptions(&buf, EncoderOptions{Format: format}).EncodeMesh(m); err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+		f, err := NewDecoder(&buf).Decode()
+		if err != nil {
+			t.Fatalf("TEST ERROR %s: %v", format, err)
+		}
+		if len(f.Meshes) != 1 {
+			t.Fatalf("%s: expected 1 mesh, got %d", format, len(f.Meshes))
+		}
+		res := f.Meshes[0]
+		for i := range m.Coords {
+			if res.Coords[i] != m.Coords[i] || res.Normals[i] != m.Normals[i] || res.Colors[i] != m.Colors[i] || res.TexCoords[i] != m.TexCoords[i] {
+				t.Errorf("%s: vertex %d differs", format, i)
+			}
+		}
+		if len(res.Triangles) != 1 || res.Triangles[0] != m.Triangles[0] {
+			t.Errorf("%s: invalid triangles %v", format, res.Triangles)
+		}
+	}
+}
+
+func TestPointWriter(t *testing.T) {
+
+	for _, format := range formats {
+		var buf bytes.Buffer
+		pw, err := NewEncoderWithOptions(&buf, EncoderOptions{Format: format}).NewPointWriter(5, true)
+		if err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+		// write the points in two steps
+		for i := 0; i < 2; i++ {
+			n := 3 - i
+			points := make([]mgl32.Vec3, n)
+			colors := make([]mgl32.Vec3, n)
+			for j := range points {
+				points[j] = mgl32.Vec3{float32(i), float32(j), 0}
+				colors[j] = mgl32.Vec3{0.2, 0.4, 1}
+			}
+			if err := pw.Write(points, colors); err != nil {
+				t.Fatalf("TEST ERROR: %v", err)
+			}
+		}
+		if err := pw.Close(); err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+
+		f, err := NewDecoder(&buf).Decode()
+		if err != nil {
+			t.Fatalf("TEST ERROR %s: %v", format, err)
+		}
+		pl := f.PointLists[0]
+		if len(pl.Points) != 5 || pl.Points[4] != (mgl32.Vec3{1, 1, 0}) {
+			t.Errorf("%s: invalid points %v", format, pl.Points)
+		}
+		if pl.Colors[0] != (mgl32.Vec3{0.2, 0.4, 1}) {
+			t.Errorf("%s: invalid color %v", format, pl.Colors[0])
+		}
+	}
+}
+
+func TestPointWriterCount(t *testing.T) {
+
+	var buf bytes.Buffer
+	pw, err := NewEncoder(&buf).NewPointWriter(2, false)
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if err := pw.Write([]mgl32.Vec3{{0, 0, 0}, {1, 1, 1}, {2, 2, 2}}, nil); err == nil {
+		t.Errorf("Expected error for too many points")
+	}
+	if err := pw.Write([]mgl32.Vec3{{0, 0, 0}}, nil); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if err := pw.Close(); err == nil {
+		t.Errorf("Expected error for missing points")
+	}
+}
+
+func TestEncodePointLists(t *testing.T) {
+
+	pls := []rex.PointList{
+		{ID: 1, Points: []mgl32.Vec3{{0, 0, 0}}, Colors: []mgl32.Vec3{{1, 1, 1}}},
+		{ID: 2, Points: []mgl32.Vec3{{1, 1, 1}, {2, 2, 2}}},
+	}
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).EncodePointLists(pls); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	f, err := NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	// colors are dropped, because not all point lists have colors
+	if len(f.PointLists[0].Points) != 3 || f.PointLists[0].Colors != nil {
+		t.Errorf("Invalid point list %+v", f.PointLists[0])
+	}
+}
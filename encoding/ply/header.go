@@ -0,0 +1,198 @@
+// Package ply converts PLY files (ASCII and binary) from and to REX point lists and meshes
+package ply
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format is the encoding of the PLY data
+type Format int
+
+const (
+	// ASCII stores all values as text
+	ASCII Format = iota
+	// BinaryLittleEndian stores all values as little endian binary data
+	BinaryLittleEndian
+	// BinaryBigEndian stores all values as big endian binary data
+	BinaryBigEndian
+)
+
+var formatNames = map[Format]string{
+	ASCII:              "ascii",
+	BinaryLittleEndian: "binary_little_endian",
+	BinaryBigEndian:    "binary_big_endian",
+}
+
+func (f Format) String() string {
+	return formatNames[f]
+}
+
+// byteOrder returns the byte order of the binary formats
+func (f Format) byteOrder() binary.ByteOrder {
+	if f == BinaryBigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// Property is a property of an element. List properties have a CountType.
+type Property struct {
+	Name      string
+	Type      string
+	CountType string // type of the list length, empty for scalar properties
+}
+
+// Element is an element (e.g. vertex or face) of the PLY file
+type Element struct {
+	Name       string
+	Count      int
+	Properties []Property
+}
+
+// index returns the index of the first property with one of the given names, -1 if not found
+func (e Element) index(names ...string) int {
+	for i, p := range e.Properties {
+		for _, n := range names {
+			if p.Name == n {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Header is the header of a PLY file
+type Header struct {
+	Format   Format
+	Comments []string
+	Elements []Element
+}
+
+// Element returns the element with the given name
+func (h *Header) Element(name string) (Element, bool) {
+	for _, e := range h.Elements {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return Element{}, false
+}
+
+// typeSizes contains the size of all scalar types, incl. the aliases of newer PLY versions
+var typeSizes = map[string]int{
+	"char": 1, "int8": 1,
+	"uchar": 1, "uint8": 1,
+	"short": 2, "int16": 2,
+	"ushort": 2, "uint16": 2,
+	"int": 4, "int32": 4,
+	"uint": 4, "uint32": 4,
+	"float": 4, "float32": 4,
+	"double": 8, "float64": 8,
+}
+
+// readHeader parses the header up to end_header
+func readHeader(r *bufio.Reader) (*Header, error) {
+
+	line, err := r.ReadString('\n')
+	if err != nil || strings.TrimSpace(line) != "ply" {
+		return nil, fmt.Errorf("Not a PLY file")
+	}
+
+	h := &Header{}
+	hasFormat := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("Reading PLY header failed: %w", err)
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "format":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("Invalid format %q", strings.TrimSpace(line))
+			}
+			found := false
+			for f, name := range formatNames {
+				if name == fields[1] {
+					h.Format, found = f, true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("Format %s is not supported", fields[1])
+			}
+			hasFormat = true
+		case "comment", "obj_info":
+			h.Comments = append(h.Comments, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0])))
+		case "element":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("Invalid element %q", strings.TrimSpace(line))
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return nil, fmt.Errorf("Invalid element count %q", fields[2])
+			}
+			h.Elements = append(h.Elements, Element{Name: fields[1], Count: count})
+		case "property":
+			if len(h.Elements) == 0 {
+				return nil, fmt.Errorf("Property w/o element")
+			}
+			var p Property
+			switch {
+			case len(fields) == 5 && fields[1] == "list":
+				p = Property{Name: fields[4], Type: fields[3], CountType: fields[2]}
+				if _, ok := typeSizes[p.CountType]; !ok {
+					return nil, fmt.Errorf("Invalid property type %s", p.CountType)
+				}
+			case len(fields) == 3:
+				p = Property{Name: fields[2], Type: fields[1]}
+			default:
+				return nil, fmt.Errorf("Invalid property %q", strings.TrimSpace(line))
+			}
+			if _, ok := typeSizes[p.Type]; !ok {
+				return nil, fmt.Errorf("Invalid property type %s", p.Type)
+			}
+			e := &h.Elements[len(h.Elements)-1]
+			e.Properties = append(e.Properties, p)
+		case "end_header":
+			if !hasFormat {
+				return nil, fmt.Errorf("PLY header has no format")
+			}
+			return h, nil
+		default:
+			return nil, fmt.Errorf("Invalid PLY header line %q", strings.TrimSpace(line))
+		}
+	}
+}
+
+// writeHeader writes the header incl. end_header
+func writeHeader(w io.Writer, h Header) error {
+
+	var sb strings.Builder
+	sb.WriteString("ply\n")
+	fmt.Fprintf(&sb, "format %s 1.0\n", h.Format)
+	for _, c := range h.Comments {
+		fmt.Fprintf(&sb, "comment %s\n", c)
+	}
+	for _, e := range h.Elements {
+		fmt.Fprintf(&sb, "element %s %d\n", e.Name, e.Count)
+		for _, p := range e.Properties {
+			if p.CountType != "" {
+				fmt.Fprintf(&sb, "property list %s %s %s\n", p.CountType, p.Type, p.Name)
+			} else {
+				fmt.Fprintf(&sb, "property %s %s\n", p.Type, p.Name)
+			}
+		}
+	}
+	sb.WriteString("end_header\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,95 @@
+package rex
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// payloads larger than this are read incrementally, so that a corrupt size
+// does not allocate memory which is never filled
+const payloadChunkSize = 1 << 20
+
+// Block is a single REX data block which can be written including its data block header
+type Block interface {
+	GetSize() int
+	Write(w io.Writer) error
+}
+
+// IDBlock is an optional interface of custom blocks which provides the block
+// ID, e.g. for the ID and reference checks of Validate
+type IDBlock interface {
+	Block
+	GetID() uint64
+}
+
+// checkSize returns ErrInvalidBlock if the required number of bytes does not fit into the block
+func checkSize(hdr DataBlockHeader, required uint64) error {
+	if required > uint64(hdr.Size) {
+		return fmt.Errorf("%w: content requires %d bytes, block size is %d bytes", ErrInvalidBlock, required, hdr.Size)
+	}
+	return nil
+}
+
+// checkCounts returns ErrLimitExceeded if one of the element counts exceeds the
+// limit, a limit of 0 disables the check
+func checkCounts(limit int, counts ...uint32) error {
+	for _, c := range counts {
+		if limit > 0 && uint64(c) > uint64(limit) {
+			return fmt.Errorf("%w: %d elements, limit is %d", ErrLimitExceeded, c, limit)
+		}
+	}
+	return nil
+}
+
+// readPayload reads n bytes from the reader
+func readPayload(r io.Reader, n int64) ([]byte, error) {
+
+	if n <= payloadChunkSize {
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	read, err := io.CopyN(&buf, r, n)
+	if read < n {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// countElements returns the largest number of elements (coordinates,
+// triangles, points, characters) of the given block
+func countElements(block Block) int {
+
+	max := func(values ...int) int {
+		m := 0
+		for _, v := range values {
+			if v > m {
+				m = v
+			}
+		}
+		return m
+	}
+
+	switch b := block.(type) {
+	case *Mesh:
+		return max(len(b.Coords), len(b.Normals), len(b.TexCoords), len(b.Colors), len(b.Triangles))
+	case *PointList:
+		return max(len(b.Points), len(b.Colors))
+	case *LineSet:
+		return len(b.Points)
+	case *Text:
+		return len(b.Text)
+	}
+	return 0
+}
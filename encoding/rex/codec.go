@@ -0,0 +1,101 @@
+package rex
+
+import (
+	"io"
+	"sync"
+)
+
+// BlockCodec decodes the payload of a data block. The reader is limited to the
+// payload size given in the data block header. The returned block is
+// responsible to write itself including the data block header.
+type BlockCodec interface {
+	Decode(r io.Reader, hdr DataBlockHeader) (Block, error)
+}
+
+// BlockCodecFunc is an adapter to use an ordinary function as BlockCodec
+type BlockCodecFunc func(r io.Reader, hdr DataBlockHeader) (Block, error)
+
+// Decode calls f(r, hdr)
+func (f BlockCodecFunc) Decode(r io.Reader, hdr DataBlockHeader) (Block, error) {
+	return f(r, hdr)
+}
+
+// elementCodec decodes a built-in block type with elements. The element counts
+// are checked against maxElements before the elements are allocated.
+type elementCodec func(r io.Reader, hdr DataBlockHeader, maxElements int) (Block, error)
+
+// Decode decodes the block w/o element limit
+func (f elementCodec) Decode(r io.Reader, hdr DataBlockHeader) (Block, error) {
+	return f(r, hdr, 0)
+}
+
+type codecKey struct {
+	blockType uint16
+	version   uint16
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[codecKey]BlockCodec)
+)
+
+func init() {
+	RegisterBlockCodec(TypeLineSet, linesetBlockVersion, elementCodec(func(r io.Reader, hdr DataBlockHeader, maxElements int) (Block, error) {
+		return readLineSet(r, hdr, maxElements)
+	}))
+	RegisterBlockCodec(TypeText, textBlockVersion, elementCodec(func(r io.Reader, hdr DataBlockHeader, maxElements int) (Block, error) {
+		return readText(r, hdr, maxElements)
+	}))
+	RegisterBlockCodec(TypePointList, pointListBlockVersion, elementCodec(func(r io.Reader, hdr DataBlockHeader, maxElements int) (Block, error) {
+		return readPointList(r, hdr, maxElements)
+	}))
+	RegisterBlockCodec(TypeMesh, meshBlockVersion, elementCodec(func(r io.Reader, hdr DataBlockHeader, maxElements int) (Block, error) {
+		return readMesh(r, hdr, maxElements)
+	}))
+	RegisterBlockCodec(TypeImage, imageBlockVersion, BlockCodecFunc(func(r io.Reader, hdr DataBlockHeader) (Block, error) {
+		return ReadImage(r, hdr)
+	}))
+	RegisterBlockCodec(TypeMaterial, materialBlockVersion, BlockCodecFunc(func(r io.Reader, hdr DataBlockHeader) (Block, error) {
+		return ReadMaterial(r, hdr)
+	}))
+	RegisterBlockCodec(TypeSceneNode, sceneNodeBlockVersion, BlockCodecFunc(func(r io.Reader, hdr DataBlockHeader) (Block, error) {
+		return ReadSceneNode(r, hdr)
+	}))
+}
+
+// RegisterBlockCodec registers a codec for the given block type and version.
+// An already registered codec (including the built-in ones) is replaced.
+// Blocks decoded by the codec which are not one of the built-in block types are
+// stored in File.CustomBlocks.
+func RegisterBlockCodec(blockType, version uint16, codec BlockCodec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[codecKey{blockType, version}] = codec
+}
+
+// lookupBlockCodec returns the codec for the given block type and version
+func lookupBlockCodec(blockType, version uint16) (BlockCodec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[codecKey{blockType, version}]
+	return codec, ok
+}
+
+// readBlock decodes the payload of a data block with the registered codec.
+// Blocks w/o codec are returned as *RawBlock. The element counts of the
+// built-in block types are checked against maxElements (0 = no limit).
+func readBlock(r io.Reader, hdr DataBlockHeader, maxElements int) (Block, error) {
+
+	codec, ok := lookupBlockCodec(hdr.Type, hdr.Version)
+	if !ok {
+		return ReadRawBlock(r, hdr)
+	}
+	if c, ok := codec.(elementCodec); ok {
+		return c(r, hdr, maxElements)
+	}
+	block, err := codec.Decode(r, hdr)
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
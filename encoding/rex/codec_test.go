@@ -0,0 +1,89 @@
+package rex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const typeTestMarker = 1000
+
+// testMarker is a proprietary block type storing a single value
+type testMarker struct {
+	ID    uint64
+	Value uint32
+}
+
+func (block *testMarker) GetSize() int {
+	return rexDataBlockHeaderSize + 4
+}
+
+func (block *testMarker) GetID() uint64 {
+	return block.ID
+}
+
+func (block *testMarker) Write(w io.Writer) error {
+	err := WriteDataBlockHeader(w, DataBlockHeader{Type: typeTestMarker, Version: 1, Size: 4, ID: block.ID})
+	if err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, block.Value)
+}
+
+// unregisterBlockCodec removes a codec which has been registered by a test
+func unregisterBlockCodec(blockType, version uint16) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	delete(codecs, codecKey{blockType, version})
+}
+
+func TestRegisterBlockCodec(t *testing.T) {
+
+	RegisterBlockCodec(typeTestMarker, 1, BlockCodecFunc(func(r io.Reader, hdr DataBlockHeader) (Block, error) {
+		block := testMarker{ID: hdr.ID}
+		err := binary.Read(r, binary.LittleEndian, &block.Value)
+		return &block, err
+	}))
+	defer unregisterBlockCodec(typeTestMarker, 1)
+
+	rexFile := File{}
+	rexFile.PointLists = append(rexFile.PointLists, PointList{ID: 1, Points: []mgl32.Vec3{{1, 2, 3}}})
+	rexFile.CustomBlocks = append(rexFile.CustomBlocks, &testMarker{ID: 2, Value: 42})
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(rexFile); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	header, res, err := NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if header.NrBlocks != 2 || len(res.UnknownBlocks) != 0 || len(res.CustomBlocks) != 1 {
+		t.Fatalf("Custom block not decoded: %v", res)
+	}
+	if m, ok := res.CustomBlocks[0].(*testMarker); !ok || m.Value != 42 || m.ID != 2 {
+		t.Fatalf("Custom block does not match: %v", res.CustomBlocks[0])
+	}
+}
+
+func TestUnregisteredBlockVersion(t *testing.T) {
+
+	// a newer mesh version w/o codec is kept as raw block
+	block := RawBlock{Header: DataBlockHeader{Type: TypeMesh, Version: 99, ID: 1}, Payload: []byte{1, 2, 3}}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(File{UnknownBlocks: []RawBlock{block}}); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	_, res, err := NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(res.Meshes) != 0 || len(res.UnknownBlocks) != 1 {
+		t.Fatalf("Expected raw block, got %v", res)
+	}
+}
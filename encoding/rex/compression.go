@@ -0,0 +1,114 @@
+package rex
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression defines how the complete REX container is compressed (e.g. .rex.gz or .rex.zst)
+type Compression int
+
+const (
+	// NoCompression writes a plain REX file
+	NoCompression Compression = iota
+	// Gzip compresses the REX file with gzip (.rex.gz)
+	Gzip
+	// Zstd compresses the REX file with Zstandard (.rex.zst)
+	Zstd
+)
+
+// ErrCompressed is returned if a compressed REX file is used where random access is required
+var ErrCompressed = errors.New("compressed REX files do not support random access")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// detectCompression returns the compression of the given file start
+func detectCompression(magic []byte) Compression {
+	if bytes.HasPrefix(magic, gzipMagic) {
+		return Gzip
+	}
+	if bytes.HasPrefix(magic, zstdMagic) {
+		return Zstd
+	}
+	return NoCompression
+}
+
+// sniffCompression looks at the first bytes of the stream w/o consuming them.
+// It returns the (possibly buffered) stream which must be used for reading.
+// Streams which implement io.Seeker but cannot seek (e.g. pipes) are buffered.
+func sniffCompression(r io.Reader) (io.Reader, Compression, error) {
+
+	if rs, ok := r.(io.ReadSeeker); ok && seekable(rs) {
+		magic := make([]byte, len(zstdMagic))
+		n, err := io.ReadFull(rs, magic)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return r, NoCompression, err
+		}
+		if _, err := rs.Seek(int64(-n), io.SeekCurrent); err != nil {
+			return r, NoCompression, err
+		}
+		return r, detectCompression(magic[:n]), nil
+	}
+
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return br, NoCompression, err
+	}
+	return br, detectCompression(magic), nil
+}
+
+// seekable checks if the stream supports seeking, *os.File implements io.Seeker
+// even for pipes
+func seekable(s io.Seeker) bool {
+	_, err := s.Seek(0, io.SeekCurrent)
+	return err == nil
+}
+
+// decompress wraps the stream with a decompressor
+func decompress(r io.Reader, c Compression) (io.ReadCloser, error) {
+
+	switch c {
+	case Gzip:
+		return gzip.NewReader(r)
+	case Zstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return nil, nil
+}
+
+// compress wraps the writer with a compressor. The returned writer must be
+// closed in order to flush the compressed data, the underlying writer is not closed.
+func compress(w io.Writer, c Compression) (io.WriteCloser, error) {
+
+	switch c {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	}
+	return nopWriteCloser{w}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
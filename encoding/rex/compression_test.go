@@ -0,0 +1,115 @@
+package rex
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+
+	mesh, mat := NewCube(1, 2, 1)
+	rexFile := File{Meshes: []Mesh{mesh}, Materials: []Material{mat}}
+
+	var plain bytes.Buffer
+	if err := NewEncoder(&plain).Encode(rexFile); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	_, expected, err := NewDecoder(&plain).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	for _, c := range []Compression{Gzip, Zstd} {
+		var buf bytes.Buffer
+		opts := EncoderOptions{Compression: c}
+		if err := NewEncoderWithOptions(&buf, opts).Encode(rexFile); err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+		data := buf.Bytes()
+		if detectCompression(data) != c {
+			t.Fatalf("Compression %d not detected", c)
+		}
+
+		// seekable and non-seekable input
+		for _, r := range []io.Reader{bytes.NewReader(data), bytes.NewBuffer(data)} {
+			dec := NewDecoderWithOptions(r, DecoderOptions{VerifyChecksum: true})
+			_, res, err := dec.Decode()
+			if err != nil {
+				t.Fatalf("TEST ERROR: %v", err)
+			}
+			if !reflect.DeepEqual(expected, res) {
+				t.Fatalf("Compression %d: decoded file does not match", c)
+			}
+		}
+
+		if _, err := NewReader(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrCompressed) {
+			t.Fatalf("Expected ErrCompressed, got %v", err)
+		}
+	}
+}
+
+func TestCompressedClose(t *testing.T) {
+
+	mesh, mat := NewCube(1, 2, 1)
+	rexFile := File{Meshes: []Mesh{mesh}, Materials: []Material{mat}}
+
+	for _, c := range []Compression{Gzip, Zstd} {
+		var buf bytes.Buffer
+		if err := NewEncoderWithOptions(&buf, EncoderOptions{Compression: c}).Encode(rexFile); err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+
+		// stop after the first block
+		dec := NewDecoder(&buf)
+		if _, _, err := dec.Next(); err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+		if dec.closer == nil {
+			t.Fatalf("Compression %d: expected decompressor", c)
+		}
+		if err := dec.Close(); err != nil {
+			t.Errorf("Compression %d: %v", c, err)
+		}
+		if dec.closer != nil {
+			t.Errorf("Compression %d: decompressor is not released", c)
+		}
+		if err := dec.Close(); err != nil {
+			t.Errorf("Compression %d: second close failed: %v", c, err)
+		}
+	}
+}
+
+func TestCompressedPipe(t *testing.T) {
+
+	mesh, mat := NewCube(1, 2, 1)
+	rexFile := File{Meshes: []Mesh{mesh}, Materials: []Material{mat}}
+
+	for _, c := range []Compression{NoCompression, Gzip, Zstd} {
+		var buf bytes.Buffer
+		if err := NewEncoderWithOptions(&buf, EncoderOptions{Compression: c}).Encode(rexFile); err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+
+		// *os.File of a pipe implements io.Seeker, but seeking fails
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+		go func() {
+			pw.Write(buf.Bytes())
+			pw.Close()
+		}()
+		_, res, err := NewDecoder(pr).Decode()
+		pr.Close()
+		if err != nil {
+			t.Fatalf("Compression %d: %v", c, err)
+		}
+		if len(res.Meshes) != 1 || len(res.Materials) != 1 {
+			t.Fatalf("Compression %d: decoded file does not match", c)
+		}
+	}
+}
@@ -1,87 +1,329 @@
 package rex
 
 import (
-	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash"
+	"hash/crc32"
 	"io"
+	"io/ioutil"
 )
 
-// Decoder which can be used to read and decode REX files from a stream
+// DecoderOptions control the behavior of the Decoder
+type DecoderOptions struct {
+	// VerifyChecksum compares the CRC32 of the data blocks with the header.
+	// Files with a CRC of 0 have no checksum and are not verified.
+	VerifyChecksum bool
+
+	// Strict aborts Decode on the first data block which cannot be decoded.
+	// Otherwise the block is dropped and reported as warning.
+	Strict bool
+
+	// Warn is called for every problem which does not abort decoding (e.g.
+	// unknown block types). The error is always a *BlockError.
+	Warn func(err error)
+
+	// The following limits protect against corrupt or hostile files. A value of 0
+	// disables the limit. Exceeding a limit returns ErrLimitExceeded and aborts Decode.
+
+	// MaxBlockSize is the maximum payload size of a single data block in bytes.
+	// Larger blocks can still be skipped without reading them.
+	MaxBlockSize uint32
+	// MaxElements is the maximum number of coordinates, normals, triangles,
+	// points, etc. of a single data block. The counts of the built-in block
+	// types are checked before the elements are allocated, blocks of custom
+	// codecs are checked after they have been decoded.
+	MaxElements int
+	// MaxMemory is the maximum total payload size of all decoded data blocks in bytes
+	MaxMemory int64
+
+	// Workers is the number of goroutines which decode the data blocks in
+	// Decode. The payloads are still read sequentially, the blocks in the
+	// resulting File keep the order of the stream. A value <= 1 decodes all
+	// blocks sequentially.
+	Workers int
+}
+
+// Decoder which can be used to read and decode REX files from a stream.
+// Besides decoding the complete file with Decode, the data blocks can be
+// iterated one by one with Next, or with NextHeader followed by ReadBlock or Skip.
+// Compressed files (.rex.gz, .rex.zst) are detected and decompressed transparently.
+// Close releases the decompressor if the iteration is stopped early.
 type Decoder struct {
-	r   io.Reader
-	buf []byte
+	r    io.Reader
+	src  io.Reader // the original input stream, used for seeking
+	opts DecoderOptions
+
+	header  *Header
+	crc     hash.Hash32
+	current DataBlockHeader
+	pending int64 // number of payload bytes of the current block which are not consumed yet
+	index   int   // index of the current block
+	offset  int64 // file offset of the current block
+	next    int64 // file offset of the next block
+	memory  int64 // total payload size of all decoded blocks
+	closer  io.Closer
 }
 
 // NewDecoder creates a new REX decoder with a given input stream
 func NewDecoder(r io.Reader) *Decoder {
-	return &Decoder{r: r}
+	return &Decoder{r: r, src: r, index: -1}
+}
+
+// NewDecoderWithOptions creates a new REX decoder with the given options
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	return &Decoder{r: r, src: r, opts: opts, index: -1}
+}
+
+// Header reads the REX header from the stream if this has not been done yet
+func (dec *Decoder) Header() (*Header, error) {
+
+	if dec.header != nil {
+		return dec.header, nil
+	}
+
+	src, compression, err := sniffCompression(dec.src)
+	if err != nil {
+		return &Header{}, err
+	}
+	if compression != NoCompression {
+		rc, err := decompress(src, compression)
+		if err != nil {
+			return &Header{}, err
+		}
+		dec.closer = rc
+		src = rc
+	}
+	dec.src = src
+	dec.r = src
+
+	header, err := ReadHeader(dec.src)
+	if err != nil {
+		return header, err
+	}
+	dec.header = header
+	dec.next = int64(header.StartAddr)
+
+	if dec.verifyChecksum() {
+		dec.crc = crc32.NewIEEE()
+		dec.r = io.TeeReader(dec.src, dec.crc)
+	}
+	return header, nil
 }
 
-// Decode reads the input from the reader and returns
-// a valid REX datastructure.
+// NextHeader reads the header of the next data block. The payload of the block
+// can then be read with ReadBlock or skipped with Skip. If the payload of the
+// previous block has not been consumed, it is skipped automatically.
+// At the end of the stream io.EOF is returned. If the stream ends within a
+// data block, a *BlockError wrapping io.ErrUnexpectedEOF is returned.
+func (dec *Decoder) NextHeader() (DataBlockHeader, error) {
+
+	if _, err := dec.Header(); err != nil {
+		return DataBlockHeader{}, err
+	}
+	if err := dec.Skip(); err != nil {
+		return DataBlockHeader{}, err
+	}
+
+	dec.index++
+	dec.offset = dec.next
+	dec.current = DataBlockHeader{}
+
+	hdr, err := ReadDataBlockHeader(dec.r)
+	if err == io.EOF {
+		dec.Close()
+		if dec.verifyChecksum() && dec.crc.Sum32() != dec.header.Crc {
+			return hdr, fmt.Errorf("%w: header %08x, data %08x", ErrChecksum, dec.header.Crc, dec.crc.Sum32())
+		}
+		return hdr, io.EOF
+	} else if err != nil {
+		return hdr, dec.blockError(err)
+	}
+
+	dec.current = hdr
+	dec.pending = int64(hdr.Size)
+	dec.next = dec.offset + rexDataBlockHeaderSize + int64(hdr.Size)
+	return hdr, nil
+}
+
+// ReadBlock reads and decodes the payload of the current data block. Blocks of
+// unknown type are returned as *RawBlock. If the block cannot be decoded, a
+// *BlockError is returned and the remaining payload is skipped, so that the
+// next block can still be read.
+func (dec *Decoder) ReadBlock() (Block, error) {
+
+	if err := dec.checkLimits(); err != nil {
+		if skipErr := dec.Skip(); skipErr != nil {
+			return nil, skipErr
+		}
+		return nil, dec.blockError(err)
+	}
+
+	lr := &io.LimitedReader{R: dec.r, N: dec.pending}
+	block, err := readBlock(lr, dec.current, dec.opts.MaxElements)
+	dec.pending = 0
+
+	// always read the rest, a failing read indicates a truncated stream
+	if skipErr := dec.discard(lr.N, false); skipErr != nil {
+		return nil, dec.blockError(skipErr)
+	}
+	if err != nil {
+		return nil, dec.blockError(err)
+	}
+
+	if err := dec.checkElements(block); err != nil {
+		return nil, dec.blockError(err)
+	}
+	return block, nil
+}
+
+// checkElements checks the number of elements of the block against the limit
+func (dec *Decoder) checkElements(block Block) error {
+	if dec.opts.MaxElements > 0 && countElements(block) > dec.opts.MaxElements {
+		return fmt.Errorf("%w: %d elements, limit is %d", ErrLimitExceeded, countElements(block), dec.opts.MaxElements)
+	}
+	return nil
+}
+
+// checkLimits checks the size of the current block against the limits
+func (dec *Decoder) checkLimits() error {
+
+	size := int64(dec.current.Size)
+	if dec.opts.MaxBlockSize > 0 && dec.current.Size > dec.opts.MaxBlockSize {
+		return fmt.Errorf("%w: block size %d, limit is %d", ErrLimitExceeded, size, dec.opts.MaxBlockSize)
+	}
+	if dec.opts.MaxMemory > 0 && dec.memory+size > dec.opts.MaxMemory {
+		return fmt.Errorf("%w: total size %d, limit is %d", ErrLimitExceeded, dec.memory+size, dec.opts.MaxMemory)
+	}
+	dec.memory += size
+	return nil
+}
+
+// Skip skips the payload of the current data block without decoding it. If the
+// input stream supports seeking and no checksum is verified, the payload is not
+// read except for its last byte, which detects truncated streams.
+func (dec *Decoder) Skip() error {
+
+	n := dec.pending
+	dec.pending = 0
+	if err := dec.discard(n, true); err != nil {
+		return dec.blockError(err)
+	}
+	return nil
+}
+
+// Next reads and decodes the next data block. At the end of the stream io.EOF is returned.
+func (dec *Decoder) Next() (DataBlockHeader, Block, error) {
+
+	hdr, err := dec.NextHeader()
+	if err != nil {
+		return hdr, nil, err
+	}
+	block, err := dec.ReadBlock()
+	return hdr, block, err
+}
+
+// Decode reads the input from the reader and returns a valid REX datastructure.
+// If the stream is truncated or a block fails in strict mode, the blocks which
+// have been decoded so far are returned together with the error.
 func (dec *Decoder) Decode() (*Header, *File, error) {
 
-	header, err := ReadHeader(dec.r)
+	header, err := dec.Header()
 	if err != nil {
 		return &Header{}, nil, err
 	}
-	file := &File{}
+	defer dec.Close()
+	file := &File{CoordinateSystem: header.CoordinateSystem}
+
+	if dec.opts.Workers > 1 {
+		return dec.decodeParallel(header, file)
+	}
 
 	for {
-		hdr, err := ReadDataBlockHeader(dec.r)
+		_, err := dec.NextHeader()
 		if err == io.EOF {
 			return header, file, nil
 		} else if err != nil {
-			fmt.Println("*************** FOUND UNEXPECTED FILE ENDING ***************")
 			return header, file, err
 		}
 
-		switch hdr.Type {
-		case typeLineSet:
-			ls, err := ReadLineSet(dec.r, hdr)
-			if err == nil {
-				file.LineSets = append(file.LineSets, *ls)
-			}
-		// case typeText: TODO
-		case typePointList:
-			pointList, err := ReadPointList(dec.r, hdr)
-			if err == nil {
-				file.PointLists = append(file.PointLists, *pointList)
+		block, err := dec.ReadBlock()
+		if err != nil {
+			if dec.abort(err) {
+				return header, file, err
 			}
-		case typeMesh:
-			mesh, err := ReadMesh(dec.r, hdr)
-			if err == nil {
-				file.Meshes = append(file.Meshes, *mesh)
-			}
-		case typeImage:
-			image, err := ReadImage(dec.r, hdr)
-			if err == nil {
-				file.Images = append(file.Images, *image)
-			}
-		case typeMaterial:
-			material, err := ReadMaterial(dec.r, hdr)
-			if err == nil {
-				file.Materials = append(file.Materials, *material)
-			}
-		case typeSceneNode:
-			sceneNode, err := ReadSceneNode(dec.r, hdr)
-			if err == nil {
-				file.SceneNodes = append(file.SceneNodes, *sceneNode)
-			}
-		default:
-			fmt.Printf("WARNING: Skipping type %d version %d sz %d id %d\n", hdr.Type, hdr.Version, hdr.Size, hdr.ID)
-			// Read block from reader and ignore
-			ignore := make([]byte, hdr.Size)
-			if err := binary.Read(dec.r, binary.LittleEndian, &ignore); err != nil {
-				fmt.Printf("Reading of unknown data block failed")
-			}
-			file.UnknownBlocks++
+			dec.warn(err)
+			continue
+		}
+		if _, ok := block.(*RawBlock); ok {
+			dec.warn(dec.blockError(ErrUnknownBlock))
 		}
+		file.add(block)
+	}
+}
+
+// Close frees the resources of the decompressor of compressed files. It must
+// be called if the blocks are not iterated up to io.EOF, Decode calls it
+// automatically. The underlying reader is not closed.
+func (dec *Decoder) Close() error {
+	if dec.closer == nil {
+		return nil
+	}
+	err := dec.closer.Close()
+	dec.closer = nil
+	return err
+}
+
+// abort returns true if Decode must stop because of the given block error
+func (dec *Decoder) abort(err error) bool {
+	return dec.opts.Strict || truncated(err) || errors.Is(err, ErrLimitExceeded)
+}
+
+// discard consumes n bytes of the input stream
+func (dec *Decoder) discard(n int64, allowSeek bool) error {
 
+	if n == 0 {
+		return nil
+	}
+	if s, ok := dec.src.(io.Seeker); ok && allowSeek && dec.crc == nil {
+		// seeking past the end succeeds, therefore the last byte is read in order
+		// to detect truncated streams. Read everything if seeking fails (e.g. pipes).
+		if _, err := s.Seek(n-1, io.SeekCurrent); err == nil {
+			n = 1
+		}
+	}
+	if _, err := io.CopyN(ioutil.Discard, dec.r, n); err != nil {
 		if err == io.EOF {
-			return header, file, nil
-		} else if err != nil {
-			return header, nil, err
+			err = io.ErrUnexpectedEOF
 		}
+		return err
+	}
+	return nil
+}
+
+// truncated checks if the error has been caused by the end of the stream and
+// not by a block which is inconsistent with its own size
+func truncated(err error) bool {
+	var blockErr *BlockError
+	return errors.As(err, &blockErr) && blockErr.Err == io.ErrUnexpectedEOF
+}
+
+func (dec *Decoder) blockError(err error) *BlockError {
+	return &BlockError{
+		Index:  dec.index,
+		Type:   dec.current.Type,
+		ID:     dec.current.ID,
+		Offset: dec.offset,
+		Err:    err,
 	}
 }
+
+func (dec *Decoder) warn(err error) {
+	if dec.opts.Warn != nil {
+		dec.opts.Warn(err)
+	}
+}
+
+func (dec *Decoder) verifyChecksum() bool {
+	return dec.opts.VerifyChecksum && dec.header != nil && dec.header.Crc != 0
+}
@@ -3,7 +3,11 @@ package rex
 import (
 	"bytes"
 	b64 "encoding/base64"
+	"errors"
+	"io"
 	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
 )
 
 func TestDecodingHeader(t *testing.T) {
@@ -29,6 +33,34 @@ func TestDecodingHeader(t *testing.T) {
 	}
 }
 
+func TestDecodingChecksum(t *testing.T) {
+
+	pl := PointList{ID: 1, Points: []mgl32.Vec3{{0, 0, 0}, {1, 1, 1}}}
+	rexFile := File{PointLists: []PointList{pl}}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(rexFile); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	data := buf.Bytes()
+
+	opts := DecoderOptions{VerifyChecksum: true}
+	header, _, err := NewDecoderWithOptions(bytes.NewReader(data), opts).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if header.Crc == 0 {
+		t.Fatal("CRC has not been written")
+	}
+
+	// corrupt the last coordinate
+	data[len(data)-1] ^= 0xff
+	_, _, err = NewDecoderWithOptions(bytes.NewReader(data), opts).Decode()
+	if !errors.Is(err, ErrChecksum) {
+		t.Fatalf("Expected checksum error, got %v", err)
+	}
+}
+
 // Base64 encoded test REX file
 // Generated by base64 mesh.rex
 const rexBuffer = `
@@ -40,3 +72,55 @@ AAAAAAAAAAAAAAAAAAAAAIA/AAAAAAAAAAAAAAA/AACAPwAAAAAAAAAAAQAAAAIAAAAFAAEARAAA
 AAAAAAAAAAAAAACAPwAAAAAAAAAA/////////38AAIA/AAAAAAAAAAD/////////fwAAgD8AAAAA
 AAAAAP////////9/AAAAAAAAgD8=
 `
+
+func TestDecodingErrors(t *testing.T) {
+
+	rexFile := File{}
+	rexFile.PointLists = append(rexFile.PointLists, PointList{ID: 1, Points: []mgl32.Vec3{{0, 0, 0}}})
+	rexFile.PointLists = append(rexFile.PointLists, PointList{ID: 2, Points: []mgl32.Vec3{{1, 1, 1}}})
+	rexFile.UnknownBlocks = append(rexFile.UnknownBlocks, RawBlock{Header: DataBlockHeader{Type: 99, ID: 3}})
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(rexFile); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	data := buf.Bytes()
+	start := int(rexFile.Header().StartAddr)
+
+	// the number of points of the first block exceeds the block size
+	corrupt := append([]byte(nil), data...)
+	corrupt[start+rexDataBlockHeaderSize] = 100
+
+	var warnings []error
+	opts := DecoderOptions{Warn: func(err error) { warnings = append(warnings, err) }}
+	_, res, err := NewDecoderWithOptions(bytes.NewReader(corrupt), opts).Decode()
+	if err != nil {
+		t.Fatalf("Lenient decoding failed: %v", err)
+	}
+	if len(res.PointLists) != 1 || res.PointLists[0].ID != 2 {
+		t.Fatalf("Expected the second point list only, got %v", res.PointLists)
+	}
+	if len(warnings) != 2 || !errors.Is(warnings[1], ErrUnknownBlock) {
+		t.Fatalf("Unexpected warnings: %v", warnings)
+	}
+	var blockErr *BlockError
+	if !errors.As(warnings[0], &blockErr) || blockErr.Index != 0 || blockErr.ID != 1 || blockErr.Offset != int64(start) {
+		t.Fatalf("Unexpected block error: %v", warnings[0])
+	}
+
+	opts.Strict = true
+	_, _, err = NewDecoderWithOptions(bytes.NewReader(corrupt), opts).Decode()
+	if !errors.As(err, &blockErr) || blockErr.ID != 1 {
+		t.Fatalf("Strict decoding must fail with block error, got %v", err)
+	}
+
+	// truncated files always fail, but return the blocks decoded so far
+	opts.Strict = false
+	_, res, err = NewDecoderWithOptions(bytes.NewReader(data[:len(data)-4]), opts).Decode()
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.As(err, &blockErr) || blockErr.Index != 2 {
+		t.Fatalf("Expected truncation error, got %v", err)
+	}
+	if len(res.PointLists) != 2 {
+		t.Fatalf("Expected 2 point lists, got %d", len(res.PointLists))
+	}
+}
@@ -1,12 +1,20 @@
 package rex
 
 import (
+	"hash/crc32"
 	"io"
 )
 
+// EncoderOptions control the behavior of the Encoder
+type EncoderOptions struct {
+	// Compression compresses the complete REX file (e.g. Gzip for .rex.gz)
+	Compression Compression
+}
+
 // Encoder is used to dump a valid REX file buffer into a writer
 type Encoder struct {
-	w io.Writer
+	w    io.Writer
+	opts EncoderOptions
 }
 
 // NewEncoder creates a new REX encoder
@@ -14,62 +22,47 @@ func NewEncoder(w io.Writer) *Encoder {
 	return &Encoder{w: w}
 }
 
+// NewEncoderWithOptions creates a new REX encoder with the given options
+func NewEncoderWithOptions(w io.Writer, opts EncoderOptions) *Encoder {
+	return &Encoder{w: w, opts: opts}
+}
+
 // Encode encodes a given REX file buffer into the writer stream.
-// The function returns the number of bytes being written to the writer
-// and nil if no error occurs.
+// The CRC32 of all data blocks is computed upfront and stored in the header.
+// If compression is enabled, the complete file is compressed.
+// The function returns nil if no error occurs.
 func (enc *Encoder) Encode(r File) error {
 
-	err := r.Header().Write(enc.w)
-
-	// Write LineSet
-	for _, l := range r.LineSets {
-		err = l.Write(enc.w)
-		if err != nil {
-			return err
-		}
+	crc := crc32.NewIEEE()
+	if err := writeBlocks(crc, r); err != nil {
+		return err
 	}
 
-	// TODO TEXT
-
-	// Write PointLists
-	for _, p := range r.PointLists {
-		err = p.Write(enc.w)
-		if err != nil {
-			return err
-		}
+	w, err := compress(enc.w, enc.opts.Compression)
+	if err != nil {
+		return err
 	}
 
-	// Write Meshes
-	for _, m := range r.Meshes {
-		err = m.Write(enc.w)
-		if err != nil {
-			return err
-		}
+	header := r.Header()
+	header.Crc = crc.Sum32()
+	if err := header.Write(w); err != nil {
+		w.Close()
+		return err
 	}
-
-	// Write Materials
-	for _, m := range r.Materials {
-		err = m.Write(enc.w)
-		if err != nil {
-			return err
-		}
+	if err := writeBlocks(w, r); err != nil {
+		w.Close()
+		return err
 	}
+	return w.Close()
+}
 
-	// Write Images
-	for _, i := range r.Images {
-		err = i.Write(enc.w)
-		if err != nil {
-			return err
-		}
-	}
+// writeBlocks writes all data blocks of the file to the given writer
+func writeBlocks(w io.Writer, r File) error {
 
-	// Write SceneNodes
-	for _, i := range r.SceneNodes {
-		err = i.Write(enc.w)
-		if err != nil {
+	for _, b := range r.Blocks() {
+		if err := b.Write(w); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
@@ -0,0 +1,39 @@
+package rex
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrChecksum is returned if the CRC32 stored in the header does not match the data blocks
+	ErrChecksum = errors.New("REX checksum mismatch")
+
+	// ErrInvalidBlock is returned if the content of a data block is inconsistent with its size
+	ErrInvalidBlock = errors.New("invalid data block")
+
+	// ErrLimitExceeded is returned if a data block exceeds one of the limits set in DecoderOptions
+	ErrLimitExceeded = errors.New("decoder limit exceeded")
+
+	// ErrUnknownBlock is reported as warning if a data block type is not supported.
+	// Such blocks are kept as RawBlock.
+	ErrUnknownBlock = errors.New("unknown data block type")
+)
+
+// BlockError describes a problem with a single data block
+type BlockError struct {
+	Index  int    // index of the data block in the file
+	Type   uint16 // type of the data block (if the data block header could be read)
+	ID     uint64 // ID of the data block (if the data block header could be read)
+	Offset int64  // file offset of the data block header
+	Err    error
+}
+
+func (e *BlockError) Error() string {
+	return fmt.Sprintf("data block %d (type %d, id %d) at offset %d: %v", e.Index, e.Type, e.ID, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *BlockError) Unwrap() error {
+	return e.Err
+}
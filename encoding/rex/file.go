@@ -9,49 +9,87 @@ const (
 // either be stored locally or sent to an arbirary writer with
 // the Encoder.
 type File struct {
-	LineSets      []LineSet
-	PointLists    []PointList
-	Meshes        []Mesh
-	Materials     []Material
-	Images        []Image
-	SceneNodes    []SceneNode
-	UnknownBlocks uint
+	CoordinateSystem CoordinateSystem // if not set, DefaultCoordinateSystem is used
+	LineSets         []LineSet
+	Texts            []Text
+	PointLists       []PointList
+	Meshes           []Mesh
+	Materials        []Material
+	Images           []Image
+	SceneNodes       []SceneNode
+	CustomBlocks     []Block    // blocks decoded by registered codecs of custom block types, see IDBlock
+	UnknownBlocks    []RawBlock // blocks which are not interpreted, but written back unchanged
 }
 
 // Header generates a proper header for the File datastructure
 func (f *File) Header() *Header {
 
 	header := CreateHeader()
-
-	for _, b := range f.LineSets {
-		header.NrBlocks++
-		header.SizeBytes += (uint64)(b.GetSize())
+	if !f.CoordinateSystem.IsZero() {
+		header.CoordinateSystem = f.CoordinateSystem
 	}
 
-	for _, b := range f.PointLists {
+	for _, b := range f.Blocks() {
 		header.NrBlocks++
 		header.SizeBytes += (uint64)(b.GetSize())
 	}
 
-	for _, b := range f.Meshes {
-		header.NrBlocks++
-		header.SizeBytes += (uint64)(b.GetSize())
-	}
+	return header
+}
 
-	for _, b := range f.Materials {
-		header.NrBlocks++
-		header.SizeBytes += (uint64)(b.GetSize())
-	}
+// Blocks returns all blocks of the file in the order they are written
+func (f *File) Blocks() []Block {
 
-	for _, b := range f.Images {
-		header.NrBlocks++
-		header.SizeBytes += (uint64)(b.GetSize())
+	var blocks []Block
+	for i := range f.LineSets {
+		blocks = append(blocks, &f.LineSets[i])
 	}
-
-	for _, b := range f.SceneNodes {
-		header.NrBlocks++
-		header.SizeBytes += (uint64)(b.GetSize())
+	for i := range f.Texts {
+		blocks = append(blocks, &f.Texts[i])
+	}
+	for i := range f.PointLists {
+		blocks = append(blocks, &f.PointLists[i])
+	}
+	for i := range f.Meshes {
+		blocks = append(blocks, &f.Meshes[i])
+	}
+	for i := range f.Materials {
+		blocks = append(blocks, &f.Materials[i])
+	}
+	for i := range f.Images {
+		blocks = append(blocks, &f.Images[i])
 	}
+	for i := range f.SceneNodes {
+		blocks = append(blocks, &f.SceneNodes[i])
+	}
+	blocks = append(blocks, f.CustomBlocks...)
+	for i := range f.UnknownBlocks {
+		blocks = append(blocks, &f.UnknownBlocks[i])
+	}
+	return blocks
+}
 
-	return header
+// add appends the given block to the corresponding list of the file
+func (f *File) add(block Block) {
+
+	switch b := block.(type) {
+	case *LineSet:
+		f.LineSets = append(f.LineSets, *b)
+	case *Text:
+		f.Texts = append(f.Texts, *b)
+	case *PointList:
+		f.PointLists = append(f.PointLists, *b)
+	case *Mesh:
+		f.Meshes = append(f.Meshes, *b)
+	case *Material:
+		f.Materials = append(f.Materials, *b)
+	case *Image:
+		f.Images = append(f.Images, *b)
+	case *SceneNode:
+		f.SceneNodes = append(f.SceneNodes, *b)
+	case *RawBlock:
+		f.UnknownBlocks = append(f.UnknownBlocks, *b)
+	default:
+		f.CustomBlocks = append(f.CustomBlocks, block)
+	}
 }
@@ -0,0 +1,34 @@
+//go:build go1.18
+// +build go1.18
+
+package rex
+
+import (
+	"bytes"
+	"testing"
+)
+
+// FuzzReadBlock feeds arbitrary data block payloads to all block readers.
+// The seed corpus contains a valid block of every type, testdata/fuzz contains
+// truncated blocks and blocks with oversized element counts.
+func FuzzReadBlock(f *testing.F) {
+
+	for _, data := range sampleBlocks(f) {
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decodeBlock(data)
+	})
+}
+
+// FuzzDecode decodes arbitrary files with resource limits. Truncated files and
+// oversized element counts are part of testdata/fuzz.
+func FuzzDecode(f *testing.F) {
+
+	f.Add(streamTestFile(f))
+	f.Add(SampleRex())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		opts := DecoderOptions{MaxBlockSize: 1 << 20, MaxElements: 1 << 16, MaxMemory: 1 << 22}
+		NewDecoderWithOptions(bytes.NewReader(data), opts).Decode()
+	})
+}
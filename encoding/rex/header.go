@@ -4,26 +4,41 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"io/ioutil"
+
+	"github.com/go-gl/mathgl/mgl32"
 )
 
 const (
 	rexFileHeaderSize      = 64
 	rexDataBlockHeaderSize = 16
 
-	// Supported block types
-	typeLineSet          = 0
-	typeText             = 1
-	typePointList        = 2
-	typeMesh             = 3
-	typeImage            = 4
-	typeMaterial         = 5
-	typePeopleSimulation = 6
-	typeUnityPackage     = 7
-	typeSceneNode        = 8
+	// TypeLineSet and the following constants are the block types of the REX specification
+	TypeLineSet          = 0
+	TypeText             = 1
+	TypePointList        = 2
+	TypeMesh             = 3
+	TypeImage            = 4
+	TypeMaterial         = 5
+	TypePeopleSimulation = 6
+	TypeUnityPackage     = 7
+	TypeSceneNode        = 8
 )
 
 // Header defines the structure of the REX header
 type Header struct {
+	Magic            [4]byte
+	Version          uint16
+	Crc              uint32
+	NrBlocks         uint16
+	StartAddr        uint16
+	SizeBytes        uint64
+	Reserved         [42]byte
+	CoordinateSystem CoordinateSystem // stored right after the header
+}
+
+// rawHeader is the fixed binary layout of the REX header
+type rawHeader struct {
 	Magic     [4]byte
 	Version   uint16
 	Crc       uint32
@@ -33,6 +48,38 @@ type Header struct {
 	Reserved  [42]byte
 }
 
+// CoordinateSystem describes the spatial reference of all data blocks.
+// The offset is added to all coordinates in order to keep
+// large geo-referenced coordinates precise in float32.
+type CoordinateSystem struct {
+	SRID      uint32
+	Authority string
+	Offset    mgl32.Vec3
+}
+
+// DefaultCoordinateSystem returns the coordinate system which is used if nothing is specified
+func DefaultCoordinateSystem() CoordinateSystem {
+	return CoordinateSystem{
+		SRID:      3876,
+		Authority: "EPSG",
+	}
+}
+
+// GetSize returns the size of the coordinate system block in bytes
+func (cs CoordinateSystem) GetSize() int {
+	return 4 + 2 + len(cs.Authority) + 12
+}
+
+// IsZero returns true if no coordinate system has been set
+func (cs CoordinateSystem) IsZero() bool {
+	return cs.SRID == 0 && cs.Authority == "" && cs.Offset == mgl32.Vec3{}
+}
+
+// String nicely print coordinate system
+func (cs CoordinateSystem) String() string {
+	return fmt.Sprintf("%s:%d offset [%.3f, %.3f, %.3f]", cs.Authority, cs.SRID, cs.Offset.X(), cs.Offset.Y(), cs.Offset.Z())
+}
+
 // DataBlockHeader stores the header information of a data block
 type DataBlockHeader struct {
 	Type    uint16
@@ -44,11 +91,12 @@ type DataBlockHeader struct {
 // CreateHeader returns a valid fresh header block
 func CreateHeader() *Header {
 	header := &Header{
-		Version:   1,
-		Crc:       0,
-		NrBlocks:  0,
-		StartAddr: 86, // fixed CSB of 22 bytes
-		SizeBytes: 0,
+		Version:          1,
+		Crc:              0,
+		NrBlocks:         0,
+		StartAddr:        86, // default CSB of 22 bytes
+		SizeBytes:        0,
+		CoordinateSystem: DefaultCoordinateSystem(),
 	}
 	header.Magic[0] = 'R'
 	header.Magic[1] = 'E'
@@ -57,9 +105,15 @@ func CreateHeader() *Header {
 	return header
 }
 
-// Write converts the REX header and a dummy CSR and writes it to the given writer
+// Write converts the REX header and the coordinate system block and writes it to the given writer.
+// The StartAddr is updated according to the size of the coordinate system block.
 func (h *Header) Write(w io.Writer) error {
 
+	if len(h.CoordinateSystem.Authority) > 0xffff {
+		return fmt.Errorf("Authority name is too long (%d bytes)", len(h.CoordinateSystem.Authority))
+	}
+	h.StartAddr = uint16(rexFileHeaderSize + h.CoordinateSystem.GetSize())
+
 	var header = []interface{}{
 		h.Magic,
 		h.Version,
@@ -68,13 +122,13 @@ func (h *Header) Write(w io.Writer) error {
 		h.StartAddr,
 		h.SizeBytes,
 		h.Reserved,
-		// default CSB block
-		uint32(3876),
-		uint16(4),
-		[]byte("EPSG"),
-		float32(0.0),
-		float32(0.0),
-		float32(0.0),
+		// CSB block
+		h.CoordinateSystem.SRID,
+		uint16(len(h.CoordinateSystem.Authority)),
+		[]byte(h.CoordinateSystem.Authority),
+		h.CoordinateSystem.Offset.X(),
+		h.CoordinateSystem.Offset.Y(),
+		h.CoordinateSystem.Offset.Z(),
 	}
 	for _, v := range header {
 		err := binary.Write(w, binary.LittleEndian, v)
@@ -88,22 +142,44 @@ func (h *Header) Write(w io.Writer) error {
 // ReadHeader reads the REX header from a given file
 func ReadHeader(r io.Reader) (*Header, error) {
 
-	var header Header
-	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
-		return &Header{}, fmt.Errorf("Error during reading header %v", err)
+	var raw rawHeader
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return &Header{}, fmt.Errorf("Error during reading header: %w", err)
+	}
+	header := Header{
+		Magic:     raw.Magic,
+		Version:   raw.Version,
+		Crc:       raw.Crc,
+		NrBlocks:  raw.NrBlocks,
+		StartAddr: raw.StartAddr,
+		SizeBytes: raw.SizeBytes,
+		Reserved:  raw.Reserved,
 	}
 
 	// read coordinate system block
-	var srid uint32
 	var sz uint16
-	binary.Read(r, binary.LittleEndian, &srid)
-	binary.Read(r, binary.LittleEndian, &sz)
+	if err := binary.Read(r, binary.LittleEndian, &header.CoordinateSystem.SRID); err != nil {
+		return &Header{}, fmt.Errorf("Error during reading coordinate system: %w", err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &sz); err != nil {
+		return &Header{}, fmt.Errorf("Error during reading coordinate system: %w", err)
+	}
 	name := make([]byte, sz)
-	binary.Read(r, binary.LittleEndian, &name)
-	var x, y, z float32
-	binary.Read(r, binary.LittleEndian, &x)
-	binary.Read(r, binary.LittleEndian, &y)
-	binary.Read(r, binary.LittleEndian, &z)
+	if err := binary.Read(r, binary.LittleEndian, &name); err != nil {
+		return &Header{}, fmt.Errorf("Error during reading coordinate system: %w", err)
+	}
+	header.CoordinateSystem.Authority = string(name)
+	if err := binary.Read(r, binary.LittleEndian, &header.CoordinateSystem.Offset); err != nil {
+		return &Header{}, fmt.Errorf("Error during reading coordinate system: %w", err)
+	}
+
+	// skip any additional data until the first data block starts
+	read := rexFileHeaderSize + header.CoordinateSystem.GetSize()
+	if int(header.StartAddr) > read {
+		if _, err := io.CopyN(ioutil.Discard, r, int64(int(header.StartAddr)-read)); err != nil {
+			return &Header{}, fmt.Errorf("Error during reading header: %w", err)
+		}
+	}
 
 	return &header, nil
 }
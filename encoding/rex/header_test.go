@@ -1,7 +1,10 @@
 package rex
 
 import (
+	"bytes"
 	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
 )
 
 func TestHeader(t *testing.T) {
@@ -11,3 +14,51 @@ func TestHeader(t *testing.T) {
 		t.Error("Wrong REX version")
 	}
 }
+
+func TestHeaderCoordinateSystem(t *testing.T) {
+
+	h := CreateHeader()
+	h.CoordinateSystem = CoordinateSystem{
+		SRID:      31256,
+		Authority: "EPSG",
+		Offset:    mgl32.Vec3{-5300, 33000, 240},
+	}
+
+	var buf bytes.Buffer
+	if err := h.Write(&buf); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if buf.Len() != int(h.StartAddr) {
+		t.Fatalf("StartAddr does not match expected=%d actual=%d", buf.Len(), h.StartAddr)
+	}
+
+	res, err := ReadHeader(&buf)
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if res.CoordinateSystem != h.CoordinateSystem {
+		t.Fatalf("Coordinate system does not match: %v", res.CoordinateSystem)
+	}
+}
+
+func TestFileCoordinateSystem(t *testing.T) {
+
+	cs := CoordinateSystem{SRID: 4978, Authority: "EPSG", Offset: mgl32.Vec3{1, 2, 3}}
+	rexFile := File{CoordinateSystem: cs}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(rexFile); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	_, res, err := NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if res.CoordinateSystem != cs {
+		t.Fatalf("Coordinate system does not match: %v", res.CoordinateSystem)
+	}
+
+	if (&File{}).Header().CoordinateSystem != DefaultCoordinateSystem() {
+		t.Fatal("Default coordinate system expected")
+	}
+}
@@ -10,10 +10,11 @@ const (
 	imageBlockVersion = 1
 )
 
+// Compression types of the image data
 const (
-	raw24 = iota
-	jpeg
-	png
+	Raw24 = iota
+	Jpeg
+	Png
 )
 
 // Image datastructure
@@ -34,20 +35,24 @@ func ReadImage(r io.Reader, hdr DataBlockHeader) (*Image, error) {
 	if hdr.Version != imageBlockVersion {
 		return nil, fmt.Errorf("Image block version %d is not supported", hdr.Version)
 	}
-	if hdr.Type != typeImage {
+	if hdr.Type != TypeImage {
 		return nil, fmt.Errorf("Wrong data block type for Image: %d", hdr.Type)
 	}
 
+	if err := checkSize(hdr, 4); err != nil {
+		return nil, err
+	}
+
 	image := Image{ID: hdr.ID}
 	if err := binary.Read(r, binary.LittleEndian, &image.Compression); err != nil {
-		return nil, fmt.Errorf("Reading compression failed")
+		return nil, fmt.Errorf("Reading compression failed: %w", err)
 	}
 
-	image.Data = make([]byte, hdr.Size-4)
-
-	if err := binary.Read(r, binary.LittleEndian, &image.Data); err != nil {
-		return nil, fmt.Errorf("Reading image failed")
+	data, err := readPayload(r, int64(hdr.Size)-4)
+	if err != nil {
+		return nil, fmt.Errorf("Reading image failed: %w", err)
 	}
+	image.Data = data
 
 	return &image, nil
 }
@@ -56,7 +61,7 @@ func ReadImage(r io.Reader, hdr DataBlockHeader) (*Image, error) {
 func (block *Image) Write(w io.Writer) error {
 
 	err := WriteDataBlockHeader(w, DataBlockHeader{
-		Type:    typeImage,
+		Type:    TypeImage,
 		Version: imageBlockVersion,
 		Size:    uint32(block.GetSize() - rexDataBlockHeaderSize),
 		ID:      block.ID,
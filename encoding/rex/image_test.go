@@ -17,7 +17,7 @@ func TestWriteImage(t *testing.T) {
 	}
 	img := Image{
 		ID:          11,
-		Compression: png,
+		Compression: Png,
 		Data:        b,
 	}
 
@@ -37,7 +37,7 @@ func TestReadImage(t *testing.T) {
 	if err != nil {
 		t.Fatal("Cannot read header")
 	}
-	if hdr.ID != 11 || hdr.Type != typeImage || hdr.Version != 1 || hdr.Size != 182 {
+	if hdr.ID != 11 || hdr.Type != TypeImage || hdr.Version != 1 || hdr.Size != 182 {
 		t.Fatalf("Header has unexpected data: %v", hdr)
 	}
 
@@ -49,7 +49,7 @@ func TestReadImage(t *testing.T) {
 	if img.ID != hdr.ID {
 		t.Fatal("ID does not match")
 	}
-	if img.Compression != png {
+	if img.Compression != Png {
 		t.Fatal("Compression does not match")
 	}
 
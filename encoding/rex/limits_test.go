@@ -0,0 +1,149 @@
+package rex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// sampleBlocks returns one encoded data block (incl. header) for every block type
+func sampleBlocks(t testing.TB) [][]byte {
+
+	mesh, mat := NewCube(1, 2, 1)
+	mesh.Normals = mesh.Coords
+	blocks := []Block{
+		&LineSet{ID: 3, Colors: mgl32.Vec4{1, 0, 0, 1}, Points: []mgl32.Vec3{{0, 0, 0}, {1, 1, 1}}},
+		&Text{ID: 4, Position: mgl32.Vec3{1, 2, 3}, FontSize: 12, Text: "label"},
+		&PointList{ID: 5, Points: []mgl32.Vec3{{0, 0, 0}, {1, 1, 1}}, Colors: []mgl32.Vec3{{1, 0, 0}, {0, 1, 0}}},
+		&mesh,
+		&Image{ID: 6, Compression: Png, Data: []byte{1, 2, 3, 4}},
+		&mat,
+		&SceneNode{ID: 7, GeometryID: 1, Name: "node", Scale: mgl32.Vec3{1, 1, 1}},
+		&RawBlock{Header: DataBlockHeader{Type: TypeUnityPackage, ID: 8}, Payload: []byte{1, 2, 3}},
+	}
+
+	var res [][]byte
+	for _, b := range blocks {
+		var buf bytes.Buffer
+		if err := b.Write(&buf); err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+		res = append(res, buf.Bytes())
+	}
+	return res
+}
+
+// decodeBlock reads a single data block and must never panic
+func decodeBlock(data []byte) (Block, error) {
+	r := bytes.NewReader(data)
+	hdr, err := ReadDataBlockHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	return readBlock(r, hdr, 0)
+}
+
+func TestCorruptBlocks(t *testing.T) {
+
+	for _, data := range sampleBlocks(t) {
+		if _, err := decodeBlock(data); err != nil {
+			t.Fatalf("Valid block failed: %v", err)
+		}
+
+		// truncate the block at every position
+		for i := 0; i < len(data); i++ {
+			if _, err := decodeBlock(data[:i]); err == nil {
+				t.Fatalf("Truncated block (%d of %d bytes) did not fail", i, len(data))
+			}
+		}
+
+		// set every 32 bit value of the payload to a huge number, which
+		// hits every element count of the block once
+		for i := rexDataBlockHeaderSize; i+4 <= len(data); i++ {
+			corrupt := append([]byte(nil), data...)
+			copy(corrupt[i:], []byte{0xff, 0xff, 0xff, 0x7f})
+			decodeBlock(corrupt)
+		}
+	}
+}
+
+func TestInvalidBlockSize(t *testing.T) {
+
+	pl := PointList{ID: 1, Points: []mgl32.Vec3{{1, 2, 3}}}
+	var buf bytes.Buffer
+	pl.Write(&buf)
+	data := buf.Bytes()
+
+	// claim 2^31 points
+	copy(data[rexDataBlockHeaderSize:], []byte{0x00, 0x00, 0x00, 0x80})
+	_, err := decodeBlock(data)
+	if !errors.Is(err, ErrInvalidBlock) {
+		t.Fatalf("Expected invalid block error, got %v", err)
+	}
+}
+
+func TestDecoderLimits(t *testing.T) {
+
+	data := streamTestFile(t)
+
+	var tests = []struct {
+		name string
+		opts DecoderOptions
+	}{
+		{"MaxBlockSize", DecoderOptions{MaxBlockSize: 128}},
+		{"MaxElements", DecoderOptions{MaxElements: 20}},
+		{"MaxMemory", DecoderOptions{MaxMemory: 256}},
+	}
+	for _, test := range tests {
+		_, _, err := NewDecoderWithOptions(bytes.NewReader(data), test.opts).Decode()
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("%s: expected limit error, got %v", test.name, err)
+		}
+	}
+
+	opts := DecoderOptions{MaxBlockSize: 1 << 20, MaxElements: 1000, MaxMemory: 1 << 20}
+	if _, _, err := NewDecoderWithOptions(bytes.NewReader(data), opts).Decode(); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+}
+
+// oversizedPointList returns a point list block which claims the given number
+// of points and a matching block size, but contains no points
+func oversizedPointList(points uint32) []byte {
+	var buf bytes.Buffer
+	WriteDataBlockHeader(&buf, DataBlockHeader{Type: TypePointList, Version: pointListBlockVersion, Size: 8 + points*12, ID: 1})
+	binary.Write(&buf, binary.LittleEndian, [2]uint32{points, 0})
+	return buf.Bytes()
+}
+
+func TestElementLimitBeforeAllocation(t *testing.T) {
+
+	data := oversizedPointList(0x15555550)
+
+	// the limit is checked before the points are allocated
+	r := bytes.NewReader(data)
+	hdr, err := ReadDataBlockHeader(r)
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if _, err := readBlock(r, hdr, 1000); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Expected limit error, got %v", err)
+	}
+
+	// w/o limit the points are allocated while reading, the missing data
+	// must not allocate the claimed 4 GB
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := decodeBlock(data); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Expected truncation error, got %v", err)
+	}
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Fatalf("Truncated block allocated %d bytes", allocated)
+	}
+}
@@ -27,21 +27,33 @@ func (block *LineSet) GetSize() int {
 
 // ReadLineSet reads the block
 func ReadLineSet(r io.Reader, hdr DataBlockHeader) (*LineSet, error) {
+	return readLineSet(r, hdr, 0)
+}
+
+// readLineSet reads the block and checks the number of points against maxElements
+func readLineSet(r io.Reader, hdr DataBlockHeader, maxElements int) (*LineSet, error) {
 
 	var nrVertices uint32
-	var ls LineSet
+	ls := LineSet{ID: hdr.ID}
 
 	if err := binary.Read(r, binary.LittleEndian, &ls.Colors); err != nil {
-		return nil, fmt.Errorf("Reading failed: %v ", err)
+		return nil, fmt.Errorf("Reading failed: %w", err)
 	}
 
 	if err := binary.Read(r, binary.LittleEndian, &nrVertices); err != nil {
-		return nil, fmt.Errorf("Reading failed: %v ", err)
+		return nil, fmt.Errorf("Reading failed: %w", err)
+	}
+
+	if err := checkSize(hdr, 20+uint64(nrVertices)*12); err != nil {
+		return nil, err
+	}
+	if err := checkCounts(maxElements, nrVertices); err != nil {
+		return nil, err
 	}
 
-	ls.Points = make([]mgl32.Vec3, nrVertices)
-	if err := binary.Read(r, binary.LittleEndian, &ls.Points); err != nil {
-		return nil, fmt.Errorf("Reading coords failed: %v", err)
+	var err error
+	if ls.Points, err = readVec3s(r, int(nrVertices)); err != nil {
+		return nil, fmt.Errorf("Reading coords failed: %w", err)
 	}
 
 	return &ls, nil
@@ -56,7 +68,7 @@ func (block *LineSet) Write(w io.Writer) error {
 	}
 
 	err := WriteDataBlockHeader(w, DataBlockHeader{
-		Type:    typeLineSet,
+		Type:    TypeLineSet,
 		Version: linesetBlockVersion,
 		Size:    uint32(block.GetSize() - rexDataBlockHeaderSize),
 		ID:      block.ID,
@@ -79,19 +91,5 @@ func (block *LineSet) Write(w io.Writer) error {
 		}
 	}
 	// Points
-	for _, p := range block.Points {
-		err := binary.Write(w, binary.LittleEndian, p.X())
-		if err != nil {
-			return err
-		}
-		err = binary.Write(w, binary.LittleEndian, p.Y())
-		if err != nil {
-			return err
-		}
-		err = binary.Write(w, binary.LittleEndian, p.Z())
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return writeVec3s(w, block.Points)
 }
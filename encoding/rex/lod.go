@@ -0,0 +1,45 @@
+package rex
+
+import (
+	"sort"
+)
+
+// GroupByLod groups the meshes of one object by their LOD level
+func GroupByLod(meshes []Mesh) map[uint16][]Mesh {
+
+	levels := make(map[uint16][]Mesh)
+	for _, m := range meshes {
+		levels[m.Lod] = append(levels[m.Lod], m)
+	}
+	return levels
+}
+
+// SelectLod returns the most detailed LOD level of one object where the total
+// number of triangles fits into the given budget. If no level fits, the coarsest
+// level is returned. The meshes of the selected level are returned as well.
+func SelectLod(meshes []Mesh, maxTriangles int) (uint16, []Mesh) {
+
+	levels := GroupByLod(meshes)
+	if len(levels) == 0 {
+		return 0, nil
+	}
+
+	var lods []int
+	for lod := range levels {
+		lods = append(lods, int(lod))
+	}
+	sort.Ints(lods)
+
+	for _, lod := range lods {
+		triangles := 0
+		for _, m := range levels[uint16(lod)] {
+			triangles += len(m.Triangles)
+		}
+		if triangles <= maxTriangles {
+			return uint16(lod), levels[uint16(lod)]
+		}
+	}
+
+	coarsest := uint16(lods[len(lods)-1])
+	return coarsest, levels[coarsest]
+}
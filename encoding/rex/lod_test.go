@@ -0,0 +1,72 @@
+package rex
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func lodMesh(id uint64, lod uint16, nrTriangles int) Mesh {
+	return Mesh{
+		ID:        id,
+		Lod:       lod,
+		MaxLod:    2,
+		Coords:    []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		Triangles: make([]Triangle, nrTriangles),
+	}
+}
+
+func TestMeshLodRoundTrip(t *testing.T) {
+
+	mesh := lodMesh(1, 1, 1)
+
+	var buf bytes.Buffer
+	if err := mesh.Write(&buf); err != nil {
+		t.Fatal("Error: ", err)
+	}
+	hdr, err := ReadDataBlockHeader(&buf)
+	if err != nil {
+		t.Fatal("Cannot read header")
+	}
+	res, err := ReadMesh(&buf, hdr)
+	if err != nil {
+		t.Fatal("Error: ", err)
+	}
+	if res.Lod != 1 || res.MaxLod != 2 {
+		t.Fatalf("LOD does not match: %d/%d", res.Lod, res.MaxLod)
+	}
+}
+
+func TestSelectLod(t *testing.T) {
+
+	meshes := []Mesh{
+		lodMesh(1, 0, 1000),
+		lodMesh(2, 0, 500),
+		lodMesh(3, 1, 400),
+		lodMesh(4, 1, 200),
+		lodMesh(5, 2, 50),
+	}
+
+	if levels := GroupByLod(meshes); len(levels) != 3 || len(levels[0]) != 2 {
+		t.Fatalf("Grouping failed: %v", levels)
+	}
+
+	var tests = []struct {
+		budget int
+		lod    uint16
+		count  int
+	}{
+		{2000, 0, 2},
+		{1500, 0, 2},
+		{1000, 1, 2},
+		{100, 2, 1},
+		{10, 2, 1},
+	}
+	for _, test := range tests {
+		lod, res := SelectLod(meshes, test.budget)
+		if lod != test.lod || len(res) != test.count {
+			t.Errorf("Budget %d: expected LOD %d (%d meshes), got %d (%d meshes)", test.budget, test.lod, test.count, lod, len(res))
+		}
+	}
+}
@@ -60,7 +60,7 @@ func ReadMaterial(r io.Reader, hdr DataBlockHeader) (*Material, error) {
 		Alpha                  float32
 	}
 	if err := binary.Read(r, binary.LittleEndian, &rexMaterial); err != nil {
-		return nil, fmt.Errorf("Reading material failed")
+		return nil, fmt.Errorf("Reading material failed: %w", err)
 	}
 
 	return &Material{
@@ -80,7 +80,7 @@ func ReadMaterial(r io.Reader, hdr DataBlockHeader) (*Material, error) {
 func (block *Material) Write(w io.Writer) error {
 
 	err := WriteDataBlockHeader(w, DataBlockHeader{
-		Type:    typeMaterial,
+		Type:    TypeMaterial,
 		Version: materialBlockVersion,
 		Size:    uint32(block.GetSize() - rexDataBlockHeaderSize),
 		ID:      block.ID,
@@ -21,10 +21,13 @@ type Triangle struct {
 	V2 uint32
 }
 
-// Mesh datastructure
+// Mesh datastructure. Meshes of one object with different resolutions share
+// the same MaxLod, whereas Lod is the level of the mesh (0 is the most detailed one).
 type Mesh struct {
 	ID         uint64
 	Name       string
+	Lod        uint16
+	MaxLod     uint16
 	Coords     []mgl32.Vec3
 	Normals    []mgl32.Vec3
 	TexCoords  []mgl32.Vec2
@@ -45,6 +48,11 @@ func (block *Mesh) GetSize() int {
 
 // ReadMesh reads a REX mesh
 func ReadMesh(r io.Reader, hdr DataBlockHeader) (*Mesh, error) {
+	return readMesh(r, hdr, 0)
+}
+
+// readMesh reads a REX mesh and checks the element counts against maxElements
+func readMesh(r io.Reader, hdr DataBlockHeader, maxElements int) (*Mesh, error) {
 
 	var rexMesh struct {
 		Lod, MaxLod                                           uint16
@@ -57,41 +65,55 @@ func ReadMesh(r io.Reader, hdr DataBlockHeader) (*Mesh, error) {
 		Name                                                  [74]byte
 	}
 	if err := binary.Read(r, binary.LittleEndian, &rexMesh); err != nil {
-		return nil, fmt.Errorf("Reading MeshHeader failed: %v", err)
+		return nil, fmt.Errorf("Reading MeshHeader failed: %w", err)
+	}
+
+	if rexMesh.NameSize > meshNameMaxSize {
+		return nil, fmt.Errorf("%w: mesh name size %d", ErrInvalidBlock, rexMesh.NameSize)
+	}
+	err := checkSize(hdr, meshHeaderSize+
+		uint64(rexMesh.NrCoords)*12+
+		uint64(rexMesh.NrNormals)*12+
+		uint64(rexMesh.NrTexCoords)*8+
+		uint64(rexMesh.NrColors)*12+
+		uint64(rexMesh.NrTriangles)*12)
+	if err != nil {
+		return nil, err
+	}
+	err = checkCounts(maxElements, rexMesh.NrCoords, rexMesh.NrNormals, rexMesh.NrTexCoords, rexMesh.NrColors, rexMesh.NrTriangles)
+	if err != nil {
+		return nil, err
 	}
 
 	var mesh Mesh
 	mesh.ID = hdr.ID
 	mesh.Name = string(rexMesh.Name[:rexMesh.NameSize])
+	mesh.Lod = rexMesh.Lod
+	mesh.MaxLod = rexMesh.MaxLod
 
 	// Read coordinates
-	mesh.Coords = make([]mgl32.Vec3, rexMesh.NrCoords)
-	if err := binary.Read(r, binary.LittleEndian, &mesh.Coords); err != nil {
-		return nil, fmt.Errorf("Reading coords failed: %v", err)
+	if mesh.Coords, err = readVec3s(r, int(rexMesh.NrCoords)); err != nil {
+		return nil, fmt.Errorf("Reading coords failed: %w", err)
 	}
 
 	// Read normals
-	mesh.Normals = make([]mgl32.Vec3, rexMesh.NrNormals)
-	if err := binary.Read(r, binary.LittleEndian, &mesh.Normals); err != nil {
-		return nil, fmt.Errorf("Reading normals failed: %v", err)
+	if mesh.Normals, err = readVec3s(r, int(rexMesh.NrNormals)); err != nil {
+		return nil, fmt.Errorf("Reading normals failed: %w", err)
 	}
 
 	// Read texture
-	mesh.TexCoords = make([]mgl32.Vec2, rexMesh.NrTexCoords)
-	if err := binary.Read(r, binary.LittleEndian, &mesh.TexCoords); err != nil {
-		return nil, fmt.Errorf("Reading texture failed: %v", err)
+	if mesh.TexCoords, err = readVec2s(r, int(rexMesh.NrTexCoords)); err != nil {
+		return nil, fmt.Errorf("Reading texture failed: %w", err)
 	}
 
 	// Read color
-	mesh.Colors = make([]mgl32.Vec3, rexMesh.NrColors)
-	if err := binary.Read(r, binary.LittleEndian, &mesh.Colors); err != nil {
-		return nil, fmt.Errorf("Reading colors failed: %v", err)
+	if mesh.Colors, err = readVec3s(r, int(rexMesh.NrColors)); err != nil {
+		return nil, fmt.Errorf("Reading colors failed: %w", err)
 	}
 
 	// Read triangles
-	mesh.Triangles = make([]Triangle, rexMesh.NrTriangles)
-	if err := binary.Read(r, binary.LittleEndian, &mesh.Triangles); err != nil {
-		return nil, fmt.Errorf("Reading triangles failed: %v", err)
+	if mesh.Triangles, err = readTriangles(r, int(rexMesh.NrTriangles)); err != nil {
+		return nil, fmt.Errorf("Reading triangles failed: %w", err)
 	}
 
 	mesh.MaterialID = rexMesh.MaterialID
@@ -119,7 +141,7 @@ func (block *Mesh) Write(w io.Writer) error {
 	}
 
 	err := WriteDataBlockHeader(w, DataBlockHeader{
-		Type:    typeMesh,
+		Type:    TypeMesh,
 		Version: meshBlockVersion,
 		Size:    uint32(block.GetSize() - rexDataBlockHeaderSize),
 		ID:      block.ID,
@@ -129,8 +151,8 @@ func (block *Mesh) Write(w io.Writer) error {
 	}
 
 	var data = []interface{}{
-		uint16(0), /* lod */
-		uint16(0), /* maxLod */
+		uint16(block.Lod),
+		uint16(block.MaxLod),
 		uint32(len(block.Coords)),
 		uint32(len(block.Normals)),
 		uint32(len(block.TexCoords)),
@@ -142,7 +164,7 @@ func (block *Mesh) Write(w io.Writer) error {
 		uint32(startColors),
 		uint32(startTriangles),
 		uint64(block.MaterialID),
-		uint16(len(block.Name)),
+		uint16(nameMaxLen),
 	}
 	for _, v := range data {
 		err := binary.Write(w, binary.LittleEndian, v)
@@ -152,73 +174,30 @@ func (block *Mesh) Write(w io.Writer) error {
 	}
 
 	// Name
-	err = binary.Write(w, binary.LittleEndian, []byte(block.Name[:nameMaxLen]))
-	if err != nil {
+	var name [meshNameMaxSize]byte
+	copy(name[:], block.Name[:nameMaxLen])
+	if _, err := w.Write(name[:]); err != nil {
 		return err
 	}
 
-	for i := 0; i < meshNameMaxSize-nameMaxLen; i++ {
-		binary.Write(w, binary.LittleEndian, false)
-	}
-
 	// Coords
-	for _, c := range block.Coords {
-		writeVec3(w, c)
+	if err := writeVec3s(w, block.Coords); err != nil {
+		return err
 	}
 	// Normals
-	for _, c := range block.Normals {
-		writeVec3(w, c)
+	if err := writeVec3s(w, block.Normals); err != nil {
+		return err
 	}
 	// TexCoords
-	for _, c := range block.TexCoords {
-		writeVec2(w, c)
+	if err := writeVec2s(w, block.TexCoords); err != nil {
+		return err
 	}
 	// Colors
-	for _, c := range block.Colors {
-		writeVec3(w, c)
+	if err := writeVec3s(w, block.Colors); err != nil {
+		return err
 	}
 	// Triangles
-	for _, t := range block.Triangles {
-		err := binary.Write(w, binary.LittleEndian, t.V0)
-		if err != nil {
-			panic("Error during binary writing V0")
-		}
-		err = binary.Write(w, binary.LittleEndian, t.V1)
-		if err != nil {
-			panic("Error during binary writing V1")
-		}
-		err = binary.Write(w, binary.LittleEndian, t.V2)
-		if err != nil {
-			panic("Error during binary writing V2")
-		}
-	}
-	return nil
-}
-
-func writeVec2(w io.Writer, v mgl32.Vec2) {
-	err := binary.Write(w, binary.LittleEndian, v.X())
-	if err != nil {
-		panic("Error during binary writing Vec2")
-	}
-	err = binary.Write(w, binary.LittleEndian, v.Y())
-	if err != nil {
-		panic("Error during binary writing Vec2")
-	}
-}
-
-func writeVec3(w io.Writer, v mgl32.Vec3) {
-	err := binary.Write(w, binary.LittleEndian, v.X())
-	if err != nil {
-		panic("Error during binary writing Vec3")
-	}
-	err = binary.Write(w, binary.LittleEndian, v.Y())
-	if err != nil {
-		panic("Error during binary writing Vec3")
-	}
-	err = binary.Write(w, binary.LittleEndian, v.Z())
-	if err != nil {
-		panic("Error during binary writing Vec3")
-	}
+	return writeTriangles(w, block.Triangles)
 }
 
 // String nicely print mesh
@@ -230,6 +209,7 @@ func (m Mesh) String() string {
 	s += fmt.Sprintf("|------------------------------------------------------------|\n")
 	s += fmt.Sprintf("| Name           | %-41s |\n", m.Name)
 	s += fmt.Sprintf("| MaterialID     | %-41d |\n", m.MaterialID)
+	s += fmt.Sprintf("| LOD            | %-41s |\n", fmt.Sprintf("%d / %d", m.Lod, m.MaxLod))
 	s += fmt.Sprintf("| # Coords       | %-41d |\n", len(m.Coords))
 	s += fmt.Sprintf("| # Normals      | %-41d |\n", len(m.Normals))
 	s += fmt.Sprintf("| # Colors       | %-41d |\n", len(m.Colors))
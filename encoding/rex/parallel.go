@@ -0,0 +1,103 @@
+package rex
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// decodeJob is a single data block which is decoded by a worker
+type decodeJob struct {
+	hdr     DataBlockHeader
+	payload []byte
+	block   Block
+	err     *BlockError // prepared with the block position, Err is set if decoding fails
+}
+
+// decodeParallel reads the payloads sequentially and decodes them with a pool
+// of workers. The blocks are added to the file in the order of the stream.
+func (dec *Decoder) decodeParallel(header *Header, file *File) (*Header, *File, error) {
+
+	// the queue limits the number of payloads which are kept in memory
+	queue := make(chan *decodeJob, 2*dec.opts.Workers)
+	var wg sync.WaitGroup
+	for i := 0; i < dec.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				block, err := readBlock(bytes.NewReader(job.payload), job.hdr, dec.opts.MaxElements)
+				if err == nil {
+					err = dec.checkElements(block)
+				}
+				if err != nil {
+					job.err.Err = err
+				} else {
+					job.block = block
+				}
+				job.payload = nil
+			}
+		}()
+	}
+
+	var jobs []*decodeJob
+	var readErr error
+	for {
+		hdr, err := dec.NextHeader()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			readErr = err
+			break
+		}
+
+		payload, err := dec.readPayload()
+		if err != nil {
+			if dec.abort(err) {
+				readErr = err
+				break
+			}
+			dec.warn(err)
+			continue
+		}
+		job := &decodeJob{hdr: hdr, payload: payload, err: dec.blockError(nil)}
+		jobs = append(jobs, job)
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	for _, job := range jobs {
+		if job.block == nil {
+			if dec.abort(job.err) {
+				return header, file, job.err
+			}
+			dec.warn(job.err)
+			continue
+		}
+		if _, ok := job.block.(*RawBlock); ok {
+			job.err.Err = ErrUnknownBlock
+			dec.warn(job.err)
+		}
+		file.add(job.block)
+	}
+	return header, file, readErr
+}
+
+// readPayload reads the complete payload of the current data block w/o decoding it
+func (dec *Decoder) readPayload() ([]byte, error) {
+
+	if err := dec.checkLimits(); err != nil {
+		if skipErr := dec.Skip(); skipErr != nil {
+			return nil, skipErr
+		}
+		return nil, dec.blockError(err)
+	}
+
+	payload, err := readPayload(dec.r, dec.pending)
+	dec.pending = 0
+	if err != nil {
+		return nil, dec.blockError(err)
+	}
+	return payload, nil
+}
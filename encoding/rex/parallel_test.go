@@ -0,0 +1,75 @@
+package rex
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestDecodeParallel(t *testing.T) {
+
+	rexFile := File{}
+	for i := 0; i < 50; i++ {
+		mesh, mat := NewCube(uint64(2*i), uint64(2*i+1), float32(i))
+		rexFile.Meshes = append(rexFile.Meshes, mesh)
+		rexFile.Materials = append(rexFile.Materials, mat)
+		rexFile.PointLists = append(rexFile.PointLists, PointList{ID: uint64(1000 + i), Points: []mgl32.Vec3{{float32(i), 0, 0}}})
+	}
+	rexFile.UnknownBlocks = append(rexFile.UnknownBlocks, RawBlock{Header: DataBlockHeader{Type: 99, ID: 2000}, Payload: []byte{1}})
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(rexFile); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	data := buf.Bytes()
+
+	var warnings []error
+	sequential := DecoderOptions{Warn: func(err error) { warnings = append(warnings, err) }}
+	_, expected, err := NewDecoderWithOptions(bytes.NewReader(data), sequential).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	var parallelWarnings []error
+	parallel := DecoderOptions{Workers: 8, Warn: func(err error) { parallelWarnings = append(parallelWarnings, err) }}
+	_, res, err := NewDecoderWithOptions(bytes.NewReader(data), parallel).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if !reflect.DeepEqual(expected, res) {
+		t.Fatal("Parallel decoding does not match sequential decoding")
+	}
+	if !reflect.DeepEqual(warnings, parallelWarnings) {
+		t.Fatalf("Warnings do not match: %v %v", warnings, parallelWarnings)
+	}
+
+	// truncated files fail, but keep all complete blocks
+	_, res, err = NewDecoderWithOptions(bytes.NewReader(data[:len(data)-20]), parallel).Decode()
+	if !truncated(err) || len(res.Meshes) != 50 {
+		t.Fatalf("Expected truncation error, got %v", err)
+	}
+}
+
+func BenchmarkDecodeParallel(b *testing.B) {
+
+	rexFile := File{}
+	for i := 0; i < 16; i++ {
+		pl := benchPointList(200000)
+		pl.ID = uint64(i)
+		rexFile.PointLists = append(rexFile.PointLists, pl)
+	}
+	var buf bytes.Buffer
+	NewEncoder(&buf).Encode(rexFile)
+	data := buf.Bytes()
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		opts := DecoderOptions{Workers: 4}
+		if _, _, err := NewDecoderWithOptions(bytes.NewReader(data), opts).Decode(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
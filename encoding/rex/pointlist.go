@@ -26,27 +26,38 @@ func (block *PointList) GetSize() int {
 
 // ReadPointList reads the block
 func ReadPointList(r io.Reader, hdr DataBlockHeader) (*PointList, error) {
+	return readPointList(r, hdr, 0)
+}
+
+// readPointList reads the block and checks the element counts against maxElements
+func readPointList(r io.Reader, hdr DataBlockHeader, maxElements int) (*PointList, error) {
 
 	var nrVertices, nrColors uint32
 
 	if err := binary.Read(r, binary.LittleEndian, &nrVertices); err != nil {
-		return nil, fmt.Errorf("Reading failed: %v ", err)
+		return nil, fmt.Errorf("Reading failed: %w", err)
 	}
 
 	if err := binary.Read(r, binary.LittleEndian, &nrColors); err != nil {
-		return nil, fmt.Errorf("Reading failed: %v ", err)
+		return nil, fmt.Errorf("Reading failed: %w", err)
 	}
 
-	var pointList PointList
+	if err := checkSize(hdr, 8+(uint64(nrVertices)+uint64(nrColors))*12); err != nil {
+		return nil, err
+	}
+	if err := checkCounts(maxElements, nrVertices, nrColors); err != nil {
+		return nil, err
+	}
 
-	pointList.Points = make([]mgl32.Vec3, nrVertices)
-	if err := binary.Read(r, binary.LittleEndian, &pointList.Points); err != nil {
-		return nil, fmt.Errorf("Reading coords failed: %v", err)
+	pointList := PointList{ID: hdr.ID}
+
+	var err error
+	if pointList.Points, err = readVec3s(r, int(nrVertices)); err != nil {
+		return nil, fmt.Errorf("Reading coords failed: %w", err)
 	}
 
-	pointList.Colors = make([]mgl32.Vec3, nrColors)
-	if err := binary.Read(r, binary.LittleEndian, &pointList.Colors); err != nil {
-		return nil, fmt.Errorf("Reading colors failed: %v", err)
+	if pointList.Colors, err = readVec3s(r, int(nrColors)); err != nil {
+		return nil, fmt.Errorf("Reading colors failed: %w", err)
 	}
 	return &pointList, nil
 }
@@ -60,7 +71,7 @@ func (block *PointList) Write(w io.Writer) error {
 	}
 
 	err := WriteDataBlockHeader(w, DataBlockHeader{
-		Type:    typePointList,
+		Type:    TypePointList,
 		Version: pointListBlockVersion,
 		Size:    uint32(block.GetSize() - rexDataBlockHeaderSize),
 		ID:      block.ID,
@@ -80,34 +91,9 @@ func (block *PointList) Write(w io.Writer) error {
 		}
 	}
 	// Points
-	for _, p := range block.Points {
-		err := binary.Write(w, binary.LittleEndian, p.X())
-		if err != nil {
-			return err
-		}
-		err = binary.Write(w, binary.LittleEndian, p.Y())
-		if err != nil {
-			return err
-		}
-		err = binary.Write(w, binary.LittleEndian, p.Z())
-		if err != nil {
-			return err
-		}
+	if err := writeVec3s(w, block.Points); err != nil {
+		return err
 	}
 	// Colors
-	for _, c := range block.Colors {
-		err := binary.Write(w, binary.LittleEndian, c.X() /* red */)
-		if err != nil {
-			return err
-		}
-		err = binary.Write(w, binary.LittleEndian, c.Y() /* green */)
-		if err != nil {
-			return err
-		}
-		err = binary.Write(w, binary.LittleEndian, c.Z() /* blue */)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return writeVec3s(w, block.Colors)
 }
@@ -0,0 +1,42 @@
+package rex
+
+import (
+	"fmt"
+	"io"
+)
+
+// RawBlock stores a data block which is not interpreted by this package (e.g.
+// PeopleSimulation, UnityPackage or vendor specific types). The payload is kept
+// as it is, so that the block can be written back without losing data.
+type RawBlock struct {
+	Header  DataBlockHeader
+	Payload []byte
+}
+
+// GetSize returns the estimated size of the block in bytes
+func (block *RawBlock) GetSize() int {
+	return rexDataBlockHeaderSize + len(block.Payload)
+}
+
+// ReadRawBlock reads the payload of the block w/o interpreting it
+func ReadRawBlock(r io.Reader, hdr DataBlockHeader) (*RawBlock, error) {
+
+	payload, err := readPayload(r, int64(hdr.Size))
+	if err != nil {
+		return nil, fmt.Errorf("Reading raw block failed: %w", err)
+	}
+	return &RawBlock{Header: hdr, Payload: payload}, nil
+}
+
+// Write writes the block including the data header to the given writer.
+// The size of the data header is taken from the payload.
+func (block *RawBlock) Write(w io.Writer) error {
+
+	hdr := block.Header
+	hdr.Size = uint32(len(block.Payload))
+	if err := WriteDataBlockHeader(w, hdr); err != nil {
+		return err
+	}
+	_, err := w.Write(block.Payload)
+	return err
+}
@@ -0,0 +1,40 @@
+package rex
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestRawBlockRoundTrip(t *testing.T) {
+
+	rexFile := File{}
+	rexFile.PointLists = append(rexFile.PointLists, PointList{ID: 1, Points: []mgl32.Vec3{{1, 2, 3}}})
+	rexFile.UnknownBlocks = append(rexFile.UnknownBlocks, RawBlock{
+		Header:  DataBlockHeader{Type: TypePeopleSimulation, Version: 1, ID: 2},
+		Payload: []byte{1, 2, 3, 4, 5},
+	})
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(rexFile); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	first := append([]byte(nil), buf.Bytes()...)
+
+	_, res, err := NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(res.UnknownBlocks) != 1 || res.UnknownBlocks[0].Header.ID != 2 {
+		t.Fatalf("Unknown block not preserved: %v", res.UnknownBlocks)
+	}
+
+	buf.Reset()
+	if err := NewEncoder(&buf).Encode(*res); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if !bytes.Equal(first, buf.Bytes()) {
+		t.Fatal("Round trip is not lossless")
+	}
+}
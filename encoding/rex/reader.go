@@ -0,0 +1,69 @@
+package rex
+
+import (
+	"fmt"
+	"io"
+)
+
+// BlockInfo describes a data block and the position of its payload in the file
+type BlockInfo struct {
+	DataBlockHeader
+	Offset int64 // file offset of the payload (right after the data block header)
+}
+
+// Reader gives random access to the data blocks of a REX file. The data block
+// headers are scanned once when the reader is created, the payloads are only
+// read on demand.
+type Reader struct {
+	r      io.ReaderAt
+	Header *Header
+	Index  []BlockInfo
+}
+
+// NewReader scans the REX file with the given size and builds the block index.
+// Compressed files cannot be read randomly and return ErrCompressed.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+
+	magic := make([]byte, len(zstdMagic))
+	n, _ := r.ReadAt(magic, 0)
+	if detectCompression(magic[:n]) != NoCompression {
+		return nil, ErrCompressed
+	}
+
+	header, err := ReadHeader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	reader := &Reader{r: r, Header: header}
+
+	offset := int64(header.StartAddr)
+	for offset < size {
+		hdr, err := ReadDataBlockHeader(io.NewSectionReader(r, offset, size-offset))
+		if err != nil {
+			return nil, fmt.Errorf("Reading data block header at offset %d failed: %v", offset, err)
+		}
+		payload := offset + rexDataBlockHeaderSize
+		if payload+int64(hdr.Size) > size {
+			return nil, fmt.Errorf("Data block %d at offset %d exceeds file size", hdr.ID, offset)
+		}
+		reader.Index = append(reader.Index, BlockInfo{DataBlockHeader: hdr, Offset: payload})
+		offset = payload + int64(hdr.Size)
+	}
+	return reader, nil
+}
+
+// ReadBlock reads and decodes the block described by the given info
+func (r *Reader) ReadBlock(info BlockInfo) (Block, error) {
+	return readBlock(io.NewSectionReader(r.r, info.Offset, int64(info.Size)), info.DataBlockHeader, 0)
+}
+
+// ReadBlockByID reads and decodes the first block with the given ID
+func (r *Reader) ReadBlockByID(id uint64) (Block, error) {
+
+	for _, info := range r.Index {
+		if info.ID == id {
+			return r.ReadBlock(info)
+		}
+	}
+	return nil, fmt.Errorf("Block with ID %d not found", id)
+}
@@ -0,0 +1,36 @@
+package rex
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+
+	data := streamTestFile(t)
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(r.Index) != 4 {
+		t.Fatalf("Expected 4 blocks, got %d", len(r.Index))
+	}
+
+	block, err := r.ReadBlockByID(1)
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	mesh, ok := block.(*Mesh)
+	if !ok || len(mesh.Triangles) != 12 {
+		t.Fatalf("Mesh not decoded properly: %v", block)
+	}
+
+	if _, err := r.ReadBlockByID(42); err == nil {
+		t.Fatal("Expected error for missing block")
+	}
+
+	// truncated files must be detected while scanning
+	if _, err := NewReader(bytes.NewReader(data), int64(len(data)-1)); err == nil {
+		t.Fatal("Expected error for truncated file")
+	}
+}
@@ -45,7 +45,7 @@ func NewSceneNode(id, geomID uint64, name string) SceneNode {
 
 // GetSize returns the estimated size of the block in bytes
 func (block *SceneNode) GetSize() int {
-	return sceneNodeSize
+	return rexDataBlockHeaderSize + sceneNodeSize
 }
 
 // ReadSceneNode reads the block
@@ -59,7 +59,7 @@ func ReadSceneNode(r io.Reader, hdr DataBlockHeader) (*SceneNode, error) {
 		Sx, Sy, Sz     float32
 	}
 	if err := binary.Read(r, binary.LittleEndian, &block); err != nil {
-		return nil, fmt.Errorf("Reading SceneNode failed")
+		return nil, fmt.Errorf("Reading SceneNode failed: %w", err)
 	}
 
 	return &SceneNode{
@@ -76,7 +76,7 @@ func ReadSceneNode(r io.Reader, hdr DataBlockHeader) (*SceneNode, error) {
 func (block *SceneNode) Write(w io.Writer) error {
 
 	err := WriteDataBlockHeader(w, DataBlockHeader{
-		Type:    typeSceneNode,
+		Type:    TypeSceneNode,
 		Version: sceneNodeBlockVersion,
 		Size:    uint32(block.GetSize() - rexDataBlockHeaderSize),
 		ID:      block.ID,
@@ -0,0 +1,122 @@
+package rex
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func streamTestFile(t testing.TB) []byte {
+
+	mesh, mat := NewCube(1, 2, 1)
+	rexFile := File{}
+	rexFile.PointLists = append(rexFile.PointLists, PointList{ID: 3, Points: []mgl32.Vec3{{1, 2, 3}}})
+	rexFile.Meshes = append(rexFile.Meshes, mesh)
+	rexFile.Materials = append(rexFile.Materials, mat)
+	rexFile.SceneNodes = append(rexFile.SceneNodes, NewSceneNode(4, 1, "cube"))
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(rexFile); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecoderNext(t *testing.T) {
+
+	d := NewDecoder(bytes.NewBuffer(streamTestFile(t)))
+
+	var ids []uint64
+	for {
+		hdr, block, err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+		ids = append(ids, hdr.ID)
+
+		switch b := block.(type) {
+		case *Mesh:
+			if len(b.Triangles) != 12 {
+				t.Fatalf("Mesh not decoded properly")
+			}
+		case *SceneNode:
+			if b.GeometryID != 1 {
+				t.Fatalf("SceneNode not decoded properly")
+			}
+		}
+	}
+	if len(ids) != 4 {
+		t.Fatalf("Expected 4 blocks, got %v", ids)
+	}
+}
+
+func TestDecoderSkip(t *testing.T) {
+
+	data := streamTestFile(t)
+
+	// bytes.Buffer cannot seek, bytes.Reader can
+	for _, r := range []io.Reader{bytes.NewBuffer(data), bytes.NewReader(data)} {
+		d := NewDecoder(r)
+		var materials int
+		for {
+			hdr, err := d.NextHeader()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("TEST ERROR: %v", err)
+			}
+			if hdr.Type != TypeMaterial {
+				if err := d.Skip(); err != nil {
+					t.Fatalf("TEST ERROR: %v", err)
+				}
+				continue
+			}
+			block, err := d.ReadBlock()
+			if err != nil {
+				t.Fatalf("TEST ERROR: %v", err)
+			}
+			if block.(*Material).ID != 2 {
+				t.Fatalf("Wrong material")
+			}
+			materials++
+		}
+		if materials != 1 {
+			t.Fatalf("Expected 1 material, got %d", materials)
+		}
+	}
+}
+
+func TestDecoderSkipTruncated(t *testing.T) {
+
+	data := streamTestFile(t)
+	data = data[:len(data)-1]
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	defer pr.Close()
+	go func() {
+		pw.Write(data)
+		pw.Close()
+	}()
+
+	// seekable streams must report the truncation the same way as pipes
+	for _, r := range []io.Reader{bytes.NewReader(data), pr} {
+		d := NewDecoder(r)
+		var err error
+		for err == nil {
+			if _, err = d.NextHeader(); err == nil {
+				err = d.Skip()
+			}
+		}
+		if !truncated(err) {
+			t.Fatalf("Expected truncation error, got %v", err)
+		}
+	}
+}
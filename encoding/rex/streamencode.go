@@ -0,0 +1,128 @@
+package rex
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// StreamEncoder writes a REX file block by block without keeping the blocks in
+// memory. The header is written first and patched with the number of blocks,
+// the size and the CRC32 when the encoder is closed.
+type StreamEncoder struct {
+	w      io.WriteSeeker
+	bw     *bufio.Writer
+	header *Header
+	crc    hash.Hash32
+	start  int64
+	closed bool
+}
+
+// NewStreamEncoder creates a new REX encoder which writes blocks incrementally.
+// Close must be called in order to finish the file.
+func NewStreamEncoder(w io.WriteSeeker) *StreamEncoder {
+	return &StreamEncoder{
+		w:      w,
+		header: CreateHeader(),
+		crc:    crc32.NewIEEE(),
+		start:  -1,
+	}
+}
+
+// SetCoordinateSystem sets the coordinate system of the file. This must be
+// done before the first block is written.
+func (enc *StreamEncoder) SetCoordinateSystem(cs CoordinateSystem) error {
+	if enc.start >= 0 {
+		return errors.New("Coordinate system must be set before writing blocks")
+	}
+	enc.header.CoordinateSystem = cs
+	return nil
+}
+
+// WriteBlock writes the given block to the stream
+func (enc *StreamEncoder) WriteBlock(b Block) error {
+
+	if enc.closed {
+		return errors.New("StreamEncoder is already closed")
+	}
+	if enc.header.NrBlocks == 0xffff {
+		return fmt.Errorf("Too many blocks, REX supports max %d blocks", 0xffff)
+	}
+	if err := enc.writeHeader(); err != nil {
+		return err
+	}
+
+	cw := &countingWriter{w: io.MultiWriter(enc.bw, enc.crc)}
+	if err := b.Write(cw); err != nil {
+		return err
+	}
+	// empty geometry blocks are not written at all
+	if cw.n == 0 {
+		return nil
+	}
+	enc.header.NrBlocks++
+	enc.header.SizeBytes += uint64(cw.n)
+	return nil
+}
+
+// Close flushes all pending data and patches the header. The underlying writer is not closed.
+func (enc *StreamEncoder) Close() error {
+
+	if enc.closed {
+		return nil
+	}
+	if err := enc.writeHeader(); err != nil {
+		return err
+	}
+	enc.closed = true
+
+	if err := enc.bw.Flush(); err != nil {
+		return err
+	}
+	end, err := enc.w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := enc.w.Seek(enc.start, io.SeekStart); err != nil {
+		return err
+	}
+	enc.header.Crc = enc.crc.Sum32()
+	if err := enc.header.Write(enc.w); err != nil {
+		return err
+	}
+	_, err = enc.w.Seek(end, io.SeekStart)
+	return err
+}
+
+// writeHeader writes the preliminary header if this has not been done yet
+func (enc *StreamEncoder) writeHeader() error {
+
+	if enc.start >= 0 {
+		return nil
+	}
+	start, err := enc.w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	enc.bw = bufio.NewWriter(enc.w)
+	if err := enc.header.Write(enc.bw); err != nil {
+		return err
+	}
+	enc.start = start
+	return nil
+}
+
+// countingWriter counts the number of bytes written
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
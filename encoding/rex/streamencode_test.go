@@ -0,0 +1,91 @@
+package rex
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestStreamEncoder(t *testing.T) {
+
+	f, err := ioutil.TempFile("", "stream*.rex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	cs := CoordinateSystem{SRID: 31256, Authority: "EPSG", Offset: mgl32.Vec3{10, 20, 30}}
+	enc := NewStreamEncoder(f)
+	if err := enc.SetCoordinateSystem(cs); err != nil {
+		t.Fatal(err)
+	}
+
+	// write 10 tiles of points, plus an empty one which must be ignored
+	for i := 0; i < 10; i++ {
+		pl := PointList{ID: uint64(i)}
+		for j := 0; j < 100; j++ {
+			pl.Points = append(pl.Points, mgl32.Vec3{float32(i), float32(j), 0})
+		}
+		if err := enc.WriteBlock(&pl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.WriteBlock(&PointList{ID: 99}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DecoderOptions{VerifyChecksum: true}
+	header, res, err := NewDecoderWithOptions(bytes.NewReader(data), opts).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if header.NrBlocks != 10 || len(res.PointLists) != 10 {
+		t.Fatalf("Expected 10 blocks, got %d", header.NrBlocks)
+	}
+	if header.SizeBytes != uint64(len(data)-int(header.StartAddr)) {
+		t.Fatalf("SizeBytes does not match")
+	}
+	if header.Crc == 0 {
+		t.Fatalf("CRC has not been written")
+	}
+	if res.CoordinateSystem != cs {
+		t.Fatalf("Coordinate system does not match")
+	}
+}
+
+func TestStreamEncoderTooManyBlocks(t *testing.T) {
+
+	f, err := ioutil.TempFile("", "stream*.rex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	enc := NewStreamEncoder(f)
+	pl := PointList{ID: 1, Points: []mgl32.Vec3{{1, 2, 3}}}
+	if err := enc.WriteBlock(&pl); err != nil {
+		t.Fatal(err)
+	}
+	size := enc.bw.Buffered()
+
+	// the overflow must be detected before anything is written
+	enc.header.NrBlocks = 0xffff
+	if err := enc.WriteBlock(&pl); err == nil {
+		t.Fatalf("Expected error for too many blocks")
+	}
+	if enc.bw.Buffered() != size {
+		t.Fatalf("Block has been written although it is rejected")
+	}
+}
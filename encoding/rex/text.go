@@ -0,0 +1,106 @@
+package rex
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	textHeaderSize   = 32
+	textBlockVersion = 1
+)
+
+// Text stores a UTF-8 label which is placed at a 3D position.
+// The color is (RGBA)
+type Text struct {
+	ID       uint64
+	Color    mgl32.Vec4
+	Position mgl32.Vec3
+	FontSize float32
+	Text     string
+}
+
+// GetSize returns the estimated size of the block in bytes
+func (block *Text) GetSize() int {
+	return rexDataBlockHeaderSize + textHeaderSize + 2 + len(block.Text)
+}
+
+// ReadText reads the block
+func ReadText(r io.Reader, hdr DataBlockHeader) (*Text, error) {
+	return readText(r, hdr, 0)
+}
+
+// readText reads the block and checks the text size against maxElements
+func readText(r io.Reader, hdr DataBlockHeader, maxElements int) (*Text, error) {
+
+	var rexText struct {
+		Red, Green, Blue, Alpha float32
+		X, Y, Z                 float32
+		FontSize                float32
+		TextSize                uint16
+	}
+	if err := binary.Read(r, binary.LittleEndian, &rexText); err != nil {
+		return nil, fmt.Errorf("Reading text header failed: %w", err)
+	}
+
+	if err := checkSize(hdr, textHeaderSize+2+uint64(rexText.TextSize)); err != nil {
+		return nil, err
+	}
+	if err := checkCounts(maxElements, uint32(rexText.TextSize)); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, rexText.TextSize)
+	if err := binary.Read(r, binary.LittleEndian, &data); err != nil {
+		return nil, fmt.Errorf("Reading text failed: %w", err)
+	}
+
+	return &Text{
+		ID:       hdr.ID,
+		Color:    mgl32.Vec4{rexText.Red, rexText.Green, rexText.Blue, rexText.Alpha},
+		Position: mgl32.Vec3{rexText.X, rexText.Y, rexText.Z},
+		FontSize: rexText.FontSize,
+		Text:     string(data),
+	}, nil
+}
+
+// Write writes the text to the given writer
+func (block *Text) Write(w io.Writer) error {
+
+	if len(block.Text) > 0xffff {
+		return fmt.Errorf("Text is too long (%d bytes)", len(block.Text))
+	}
+
+	err := WriteDataBlockHeader(w, DataBlockHeader{
+		Type:    TypeText,
+		Version: textBlockVersion,
+		Size:    uint32(block.GetSize() - rexDataBlockHeaderSize),
+		ID:      block.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	var data = []interface{}{
+		float32(block.Color.X()),
+		float32(block.Color.Y()),
+		float32(block.Color.Z()),
+		float32(block.Color.W()),
+		float32(block.Position.X()),
+		float32(block.Position.Y()),
+		float32(block.Position.Z()),
+		float32(block.FontSize),
+		uint16(len(block.Text)),
+		[]byte(block.Text),
+	}
+	for _, v := range data {
+		err := binary.Write(w, binary.LittleEndian, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
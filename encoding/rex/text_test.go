@@ -0,0 +1,62 @@
+package rex
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestTextRoundTrip(t *testing.T) {
+
+	text := Text{
+		ID:       7,
+		Color:    mgl32.Vec4{1, 0, 0, 1},
+		Position: mgl32.Vec3{1, 2, 3},
+		FontSize: 24,
+		Text:     "Raum 1.04 – Büro",
+	}
+
+	var buf bytes.Buffer
+	if err := text.Write(&buf); err != nil {
+		t.Fatal("Error: ", err)
+	}
+	if buf.Len() != text.GetSize() {
+		t.Fatalf("Size does not match expected=%d actual=%d", text.GetSize(), buf.Len())
+	}
+
+	hdr, err := ReadDataBlockHeader(&buf)
+	if err != nil {
+		t.Fatal("Cannot read header")
+	}
+	if hdr.Type != TypeText || hdr.ID != 7 {
+		t.Fatalf("Header has unexpected data: %v", hdr)
+	}
+
+	res, err := ReadText(&buf, hdr)
+	if err != nil {
+		t.Fatal("Error: ", err)
+	}
+	if *res != text {
+		t.Fatalf("Text does not match: %v", res)
+	}
+}
+
+func TestDecodingText(t *testing.T) {
+
+	rexFile := File{}
+	rexFile.Texts = append(rexFile.Texts, Text{ID: 1, Color: mgl32.Vec4{1, 1, 1, 1}, FontSize: 12, Text: "label"})
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(rexFile); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	header, res, err := NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if header.NrBlocks != 1 || len(res.Texts) != 1 || res.Texts[0].Text != "label" {
+		t.Fatalf("Text block not decoded: %v", res.Texts)
+	}
+}
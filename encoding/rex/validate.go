@@ -0,0 +1,161 @@
+package rex
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Issue describes a semantic problem of a REX file
+type Issue struct {
+	BlockID uint64
+	Message string
+}
+
+// String nicely print issue
+func (i Issue) String() string {
+	return fmt.Sprintf("block %d: %s", i.BlockID, i.Message)
+}
+
+// Validate checks the semantic consistency of the given REX file. This covers
+// references between blocks (materials, textures, scenegraph geometries), the
+// mesh topology, and the uniqueness of block IDs. An empty list is returned if
+// no issues are found.
+func Validate(f File) []Issue {
+
+	var issues []Issue
+	report := func(id uint64, format string, args ...interface{}) {
+		issues = append(issues, Issue{BlockID: id, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// unique block IDs of all blocks incl. custom blocks
+	ids := make(map[uint64]int)
+	for _, b := range f.Blocks() {
+		if id, ok := blockID(b); ok {
+			ids[id]++
+		}
+	}
+	var duplicates []uint64
+	for id, count := range ids {
+		if count > 1 {
+			duplicates = append(duplicates, id)
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i] < duplicates[j] })
+	for _, id := range duplicates {
+		report(id, "ID is used by %d blocks", ids[id])
+	}
+
+	images := make(map[uint64]bool)
+	for _, img := range f.Images {
+		images[img.ID] = true
+	}
+	materials := make(map[uint64]bool)
+	for _, mat := range f.Materials {
+		materials[mat.ID] = true
+		textures := []struct {
+			name string
+			id   uint64
+		}{
+			{"ambient", mat.KaTextureID},
+			{"diffuse", mat.KdTextureID},
+			{"specular", mat.KsTextureID},
+		}
+		for _, tex := range textures {
+			if tex.id != NotSpecified && !images[tex.id] {
+				report(mat.ID, "%s texture %d does not exist", tex.name, tex.id)
+			}
+		}
+	}
+
+	geometries := make(map[uint64]bool)
+	for _, b := range f.LineSets {
+		geometries[b.ID] = true
+	}
+	for _, b := range f.Texts {
+		geometries[b.ID] = true
+	}
+	for _, b := range f.PointLists {
+		geometries[b.ID] = true
+	}
+	// custom blocks may be referenced as proprietary geometries
+	for _, b := range f.CustomBlocks {
+		if id, ok := blockID(b); ok {
+			geometries[id] = true
+		}
+	}
+
+	for _, m := range f.Meshes {
+		geometries[m.ID] = true
+
+		if m.MaterialID != NotSpecified && !materials[m.MaterialID] {
+			report(m.ID, "material %d does not exist", m.MaterialID)
+		}
+		if len(m.Normals) > 0 && len(m.Normals) != len(m.Coords) {
+			report(m.ID, "%d normals for %d coordinates", len(m.Normals), len(m.Coords))
+		}
+		if len(m.TexCoords) > 0 && len(m.TexCoords) != len(m.Coords) {
+			report(m.ID, "%d texture coordinates for %d coordinates", len(m.TexCoords), len(m.Coords))
+		}
+		if len(m.Colors) > 0 && len(m.Colors) != len(m.Coords) {
+			report(m.ID, "%d colors for %d coordinates", len(m.Colors), len(m.Coords))
+		}
+
+		// only report the first invalid triangle to keep the list short
+		nrCoords := uint32(len(m.Coords))
+		invalid := 0
+		first := -1
+		for i, t := range m.Triangles {
+			if t.V0 >= nrCoords || t.V1 >= nrCoords || t.V2 >= nrCoords {
+				if first < 0 {
+					first = i
+				}
+				invalid++
+			}
+		}
+		if invalid > 0 {
+			report(m.ID, "%d triangles reference invalid coordinates (first is triangle %d: %v)", invalid, first, m.Triangles[first])
+		}
+	}
+
+	for _, p := range f.PointLists {
+		if len(p.Colors) > 0 && len(p.Colors) != len(p.Points) {
+			report(p.ID, "%d colors for %d points", len(p.Colors), len(p.Points))
+		}
+	}
+
+	// a geometry ID of 0 is used for group nodes w/o geometry
+	for _, n := range f.SceneNodes {
+		if n.GeometryID != 0 && n.GeometryID != NotSpecified && !geometries[n.GeometryID] {
+			report(n.ID, "geometry %d does not exist", n.GeometryID)
+		}
+	}
+
+	return issues
+}
+
+// blockID returns the ID of the block. Custom blocks provide their ID by
+// implementing IDBlock, false is returned otherwise.
+func blockID(b Block) (uint64, bool) {
+
+	switch b := b.(type) {
+	case *LineSet:
+		return b.ID, true
+	case *Text:
+		return b.ID, true
+	case *PointList:
+		return b.ID, true
+	case *Mesh:
+		return b.ID, true
+	case *Material:
+		return b.ID, true
+	case *Image:
+		return b.ID, true
+	case *SceneNode:
+		return b.ID, true
+	case *RawBlock:
+		return b.Header.ID, true
+	case IDBlock:
+		return b.GetID(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,71 @@
+package rex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestValidateValid(t *testing.T) {
+
+	mesh, mat := NewCube(1, 2, 1)
+	f := File{
+		Meshes:     []Mesh{mesh},
+		Materials:  []Material{mat},
+		SceneNodes: []SceneNode{NewSceneNode(3, 1, "cube"), NewSceneNode(4, 0, "group")},
+	}
+	if issues := Validate(f); len(issues) != 0 {
+		t.Fatalf("Expected no issues, got %v", issues)
+	}
+}
+
+func TestValidateIssues(t *testing.T) {
+
+	mesh, mat := NewCube(1, 2, 1)
+	mesh.MaterialID = 5
+	mesh.Normals = []mgl32.Vec3{{0, 0, 1}}
+	mesh.Triangles = append(mesh.Triangles, Triangle{0, 1, 100})
+	mat.KdTextureID = 6
+
+	f := File{
+		Meshes:     []Mesh{mesh},
+		Materials:  []Material{mat},
+		PointLists: []PointList{{ID: 2, Points: []mgl32.Vec3{{0, 0, 0}}}},
+		SceneNodes: []SceneNode{NewSceneNode(3, 7, "missing")},
+	}
+
+	issues := Validate(f)
+	expected := []string{
+		"block 2: ID is used by 2 blocks",
+		"block 2: diffuse texture 6 does not exist",
+		"block 1: material 5 does not exist",
+		"block 1: 1 normals for 24 coordinates",
+		"block 1: 1 triangles reference invalid coordinates",
+		"block 3: geometry 7 does not exist",
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %v", len(expected), issues)
+	}
+	for i, e := range expected {
+		if !strings.HasPrefix(issues[i].String(), e) {
+			t.Errorf("Expected %q, got %q", e, issues[i])
+		}
+	}
+}
+
+func TestValidateCustomBlocks(t *testing.T) {
+
+	mesh, mat := NewCube(1, 2, 1)
+	f := File{
+		Meshes:       []Mesh{mesh},
+		Materials:    []Material{mat},
+		CustomBlocks: []Block{&testMarker{ID: 1, Value: 42}, &testMarker{ID: 5, Value: 1}},
+		SceneNodes:   []SceneNode{NewSceneNode(3, 5, "custom geometry")},
+	}
+
+	issues := Validate(f)
+	if len(issues) != 1 || !strings.HasPrefix(issues[0].String(), "block 1: ID is used by 2 blocks") {
+		t.Fatalf("Expected duplicate ID of the custom block, got %v", issues)
+	}
+}
@@ -0,0 +1,156 @@
+package rex
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// geometry arrays are converted in chunks of this size (divisible by 8 and 12)
+const vectorBufferSize = 96 * 1024
+
+var vectorBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, vectorBufferSize)
+		return &buf
+	},
+}
+
+// readChunks reads n elements of the given size and calls decode for every
+// chunk. The chunk contains the elements starting at index start.
+func readChunks(r io.Reader, n, size int, decode func(chunk []byte, start int)) error {
+
+	bp := vectorBuffers.Get().(*[]byte)
+	defer vectorBuffers.Put(bp)
+	perChunk := len(*bp) / size
+
+	for start := 0; start < n; start += perChunk {
+		count := n - start
+		if count > perChunk {
+			count = perChunk
+		}
+		chunk := (*bp)[:count*size]
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		decode(chunk, start)
+	}
+	return nil
+}
+
+// writeChunks writes n elements of the given size. encode must fill the chunk
+// with the elements starting at index start.
+func writeChunks(w io.Writer, n, size int, encode func(chunk []byte, start int)) error {
+
+	bp := vectorBuffers.Get().(*[]byte)
+	defer vectorBuffers.Put(bp)
+	perChunk := len(*bp) / size
+
+	for start := 0; start < n; start += perChunk {
+		count := n - start
+		if count > perChunk {
+			count = perChunk
+		}
+		chunk := (*bp)[:count*size]
+		encode(chunk, start)
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getFloat32(b []byte) float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(b))
+}
+
+func putFloat32(b []byte, v float32) {
+	binary.LittleEndian.PutUint32(b, math.Float32bits(v))
+}
+
+// initialCapacity returns the capacity for n elements of the given size. The
+// slices grow while reading, therefore a corrupt count does not allocate
+// memory which is never filled.
+func initialCapacity(n, size int) int {
+	if perChunk := vectorBufferSize / size; n > perChunk {
+		return perChunk
+	}
+	return n
+}
+
+// readVec3s reads n little endian float32 triples
+func readVec3s(r io.Reader, n int) ([]mgl32.Vec3, error) {
+	dst := make([]mgl32.Vec3, 0, initialCapacity(n, 12))
+	err := readChunks(r, n, 12, func(chunk []byte, start int) {
+		for b := chunk; len(b) >= 12; b = b[12:] {
+			dst = append(dst, mgl32.Vec3{getFloat32(b[0:]), getFloat32(b[4:]), getFloat32(b[8:])})
+		}
+	})
+	return dst, err
+}
+
+// readVec2s reads n little endian float32 pairs
+func readVec2s(r io.Reader, n int) ([]mgl32.Vec2, error) {
+	dst := make([]mgl32.Vec2, 0, initialCapacity(n, 8))
+	err := readChunks(r, n, 8, func(chunk []byte, start int) {
+		for b := chunk; len(b) >= 8; b = b[8:] {
+			dst = append(dst, mgl32.Vec2{getFloat32(b[0:]), getFloat32(b[4:])})
+		}
+	})
+	return dst, err
+}
+
+// readTriangles reads n little endian uint32 triples
+func readTriangles(r io.Reader, n int) ([]Triangle, error) {
+	dst := make([]Triangle, 0, initialCapacity(n, 12))
+	err := readChunks(r, n, 12, func(chunk []byte, start int) {
+		for b := chunk; len(b) >= 12; b = b[12:] {
+			dst = append(dst, Triangle{binary.LittleEndian.Uint32(b[0:]), binary.LittleEndian.Uint32(b[4:]), binary.LittleEndian.Uint32(b[8:])})
+		}
+	})
+	return dst, err
+}
+
+// writeVec3s writes the vectors as little endian float32 triples
+func writeVec3s(w io.Writer, src []mgl32.Vec3) error {
+	return writeChunks(w, len(src), 12, func(chunk []byte, start int) {
+		v := src[start : start+len(chunk)/12]
+		for i := range v {
+			b := chunk[i*12 : i*12+12]
+			putFloat32(b[0:], v[i][0])
+			putFloat32(b[4:], v[i][1])
+			putFloat32(b[8:], v[i][2])
+		}
+	})
+}
+
+// writeVec2s writes the vectors as little endian float32 pairs
+func writeVec2s(w io.Writer, src []mgl32.Vec2) error {
+	return writeChunks(w, len(src), 8, func(chunk []byte, start int) {
+		v := src[start : start+len(chunk)/8]
+		for i := range v {
+			b := chunk[i*8 : i*8+8]
+			putFloat32(b[0:], v[i][0])
+			putFloat32(b[4:], v[i][1])
+		}
+	})
+}
+
+// writeTriangles writes the triangles as little endian uint32 triples
+func writeTriangles(w io.Writer, src []Triangle) error {
+	return writeChunks(w, len(src), 12, func(chunk []byte, start int) {
+		t := src[start : start+len(chunk)/12]
+		for i := range t {
+			b := chunk[i*12 : i*12+12]
+			binary.LittleEndian.PutUint32(b[0:], t[i].V0)
+			binary.LittleEndian.PutUint32(b[4:], t[i].V1)
+			binary.LittleEndian.PutUint32(b[8:], t[i].V2)
+		}
+	})
+}
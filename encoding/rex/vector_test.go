@@ -0,0 +1,138 @@
+package rex
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func benchPointList(n int) PointList {
+	pl := PointList{ID: 1, Points: make([]mgl32.Vec3, n), Colors: make([]mgl32.Vec3, n)}
+	for i := 0; i < n; i++ {
+		pl.Points[i] = mgl32.Vec3{float32(i), float32(i) * 0.5, -float32(i)}
+		pl.Colors[i] = mgl32.Vec3{0.1, 0.2, 0.3}
+	}
+	return pl
+}
+
+func TestVectorRoundTrip(t *testing.T) {
+
+	// more points than fit into one chunk
+	pl := benchPointList(20000)
+
+	var buf bytes.Buffer
+	if err := pl.Write(&buf); err != nil {
+		t.Fatal("Error: ", err)
+	}
+	hdr, err := ReadDataBlockHeader(&buf)
+	if err != nil {
+		t.Fatal("Cannot read header")
+	}
+	res, err := ReadPointList(&buf, hdr)
+	if err != nil {
+		t.Fatal("Error: ", err)
+	}
+	for i := range pl.Points {
+		if res.Points[i] != pl.Points[i] || res.Colors[i] != pl.Colors[i] {
+			t.Fatalf("Point %d does not match", i)
+		}
+	}
+}
+
+func TestMeshRoundTrip(t *testing.T) {
+
+	mesh := Mesh{
+		ID:         1,
+		Name:       strings.Repeat("x", 100),
+		Coords:     []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		Normals:    []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+		TexCoords:  []mgl32.Vec2{{0, 0}, {1, 0}, {0, 1}},
+		Colors:     []mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		Triangles:  []Triangle{{0, 1, 2}},
+		MaterialID: NotSpecified,
+	}
+
+	var buf bytes.Buffer
+	if err := mesh.Write(&buf); err != nil {
+		t.Fatal("Error: ", err)
+	}
+	hdr, err := ReadDataBlockHeader(&buf)
+	if err != nil {
+		t.Fatal("Cannot read header")
+	}
+	res, err := ReadMesh(&buf, hdr)
+	if err != nil {
+		t.Fatal("Error: ", err)
+	}
+	if res.Name != mesh.Name[:meshNameMaxSize] {
+		t.Fatalf("Name is not truncated: %s", res.Name)
+	}
+	if res.TexCoords[2] != mesh.TexCoords[2] || res.Colors[1] != mesh.Colors[1] || res.Triangles[0] != mesh.Triangles[0] {
+		t.Fatalf("Mesh does not match: %v", res)
+	}
+}
+
+func BenchmarkWritePointList(b *testing.B) {
+
+	pl := benchPointList(1000000)
+	b.SetBytes(int64(pl.GetSize()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := pl.Write(ioutil.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadPointList(b *testing.B) {
+
+	pl := benchPointList(1000000)
+	var buf bytes.Buffer
+	pl.Write(&buf)
+	data := buf.Bytes()
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := bytes.NewReader(data)
+		hdr, _ := ReadDataBlockHeader(r)
+		if _, err := ReadPointList(r, hdr); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteMesh(b *testing.B) {
+
+	pl := benchPointList(1000000)
+	mesh := Mesh{ID: 1, Coords: pl.Points, Normals: pl.Points, Colors: pl.Colors, Triangles: make([]Triangle, 2000000)}
+	b.SetBytes(int64(mesh.GetSize()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := mesh.Write(ioutil.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadMesh(b *testing.B) {
+
+	pl := benchPointList(1000000)
+	mesh := Mesh{ID: 1, Coords: pl.Points, Normals: pl.Points, Colors: pl.Colors, Triangles: make([]Triangle, 2000000)}
+	var buf bytes.Buffer
+	mesh.Write(&buf)
+	data := buf.Bytes()
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := bytes.NewReader(data)
+		hdr, _ := ReadDataBlockHeader(r)
+		if _, err := ReadMesh(r, hdr); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package stl converts STL files (ASCII and binary) from and to REX meshes
+package stl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// DefaultTolerance is the distance which is used to weld vertices by default
+const DefaultTolerance = 1e-5
+
+const (
+	headerSize = 80
+	facetSize  = 50 // normal, 3 vertices and the attribute byte count
+
+	// headerPrefix is written before the mesh name in binary files, because
+	// the header must not start with solid
+	headerPrefix = "binary STL "
+)
+
+// DecoderOptions control the STL import
+type DecoderOptions struct {
+	// Tolerance is the maximum distance of two vertices which are welded. If 0
+	// only vertices with identical positions are welded.
+	Tolerance float32
+	// SmoothNormals welds vertices independent of their facet normals and
+	// averages the normals. Otherwise vertices are only welded if their facet
+	// normals are equal, which keeps hard edges.
+	SmoothNormals bool
+}
+
+// Decoder reads an ASCII or binary STL file and converts it into meshes. Every
+// solid of an ASCII file becomes a mesh. The facet normals are stored as
+// vertex normals, missing normals are computed from the vertices.
+type Decoder struct {
+	r    io.Reader
+	opts DecoderOptions
+}
+
+// NewDecoder creates a new STL decoder which uses the DefaultTolerance
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r, opts: DecoderOptions{Tolerance: DefaultTolerance}}
+}
+
+// NewDecoderWithOptions creates a new STL decoder with the given options
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	return &Decoder{r: r, opts: opts}
+}
+
+// ReadFile reads the given STL file
+func ReadFile(name string) (*rex.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewDecoder(f).Decode()
+}
+
+// Decode reads the STL file and returns a REX file with the meshes
+func (dec *Decoder) Decode() (*rex.File, error) {
+
+	br := bufio.NewReaderSize(dec.r, 64*1024)
+	// ASCII files start with solid, but some binary files too, therefore
+	// the first facet is checked as well
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	var meshes []rex.Mesh
+	if bytes.HasPrefix(head, []byte("solid")) && (bytes.Contains(head, []byte("facet")) || bytes.Contains(head, []byte("endsolid"))) {
+		meshes, err = dec.decodeASCII(br)
+	} else {
+		meshes, err = dec.decodeBinary(br)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f := &rex.File{}
+	for i := range meshes {
+		meshes[i].ID = uint64(i + 1)
+		f.Meshes = append(f.Meshes, meshes[i])
+	}
+	return f, nil
+}
+
+func (dec *Decoder) decodeBinary(r io.Reader) ([]rex.Mesh, error) {
+
+	var hdr struct {
+		Header    [headerSize]byte
+		Triangles uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("Reading STL header failed: %w", err)
+	}
+
+	w := newWelder(dec.opts)
+	name := strings.TrimRight(string(hdr.Header[:]), "\x00")
+	w.mesh.Name = strings.TrimSpace(strings.TrimPrefix(name, headerPrefix))
+
+	var facet [facetSize]byte
+	for i := uint32(0); i < hdr.Triangles; i++ {
+		if _, err := io.ReadFull(r, facet[:]); err != nil {
+			return nil, fmt.Errorf("Reading triangle %d failed: %w", i, err)
+		}
+		var v [4]mgl32.Vec3
+		for j := range v {
+			for k := 0; k < 3; k++ {
+				v[j][k] = math.Float32frombits(binary.LittleEndian.Uint32(facet[(j*3+k)*4:]))
+			}
+		}
+		w.addFacet(v[0], [3]mgl32.Vec3{v[1], v[2], v[3]})
+	}
+	return []rex.Mesh{w.finish()}, nil
+}
+
+func (dec *Decoder) decodeASCII(r io.Reader) ([]rex.Mesh, error) {
+
+	var meshes []rex.Mesh
+	var w *welder
+	var normal mgl32.Vec3
+	var vertices []mgl32.Vec3
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "solid":
+			w = newWelder(dec.opts)
+			w.mesh.Name = strings.Join(fields[1:], " ")
+		case "endsolid":
+			if w != nil {
+				meshes = append(meshes, w.finish())
+				w = nil
+			}
+		case "facet":
+			if len(fields) != 5 || fields[1] != "normal" {
+				return nil, fmt.Errorf("line %d: invalid facet", line)
+			}
+			v, err := parseVec3(fields[2:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			normal = v
+			vertices = vertices[:0]
+		case "vertex":
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("line %d: invalid vertex", line)
+			}
+			v, err := parseVec3(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			vertices = append(vertices, v)
+		case "endfacet":
+			if w == nil || len(vertices) != 3 {
+				return nil, fmt.Errorf("line %d: facet must have 3 vertices inside a solid", line)
+			}
+			w.addFacet(normal, [3]mgl32.Vec3{vertices[0], vertices[1], vertices[2]})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// tolerate a missing endsolid
+	if w != nil {
+		meshes = append(meshes, w.finish())
+	}
+	return meshes, nil
+}
+
+func parseVec3(fields []string) (mgl32.Vec3, error) {
+	var v mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		f, err := strconv.ParseFloat(fields[i], 32)
+		if err != nil {
+			return v, err
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+// cell is a cell of the welding grid
+type cell struct {
+	x, y, z int64
+}
+
+// welder merges the vertices of the unindexed triangles. The vertices are
+// sorted into a grid with the tolerance as cell size, therefore only the
+// neighboring cells must be searched.
+type welder struct {
+	opts DecoderOptions
+	mesh rex.Mesh
+	grid map[cell][]uint32
+}
+
+func newWelder(opts DecoderOptions) *welder {
+	return &welder{
+		opts: opts,
+		mesh: rex.Mesh{MaterialID: rex.NotSpecified},
+		grid: make(map[cell][]uint32),
+	}
+}
+
+func (w *welder) addFacet(normal mgl32.Vec3, vertices [3]mgl32.Vec3) {
+
+	computed := vertices[1].Sub(vertices[0]).Cross(vertices[2].Sub(vertices[0]))
+	if computed.Len() == 0 {
+		return // degenerated triangle
+	}
+	if l := normal.Len(); l == 0 || math.IsNaN(float64(l)) {
+		normal = computed
+	}
+	normal = normal.Normalize()
+
+	t := rex.Triangle{
+		V0: w.index(vertices[0], normal),
+		V1: w.index(vertices[1], normal),
+		V2: w.index(vertices[2], normal),
+	}
+	// triangles which are smaller than the tolerance collapse
+	if t.V0 != t.V1 && t.V1 != t.V2 && t.V0 != t.V2 {
+		w.mesh.Triangles = append(w.mesh.Triangles, t)
+	}
+}
+
+func (w *welder) cellOf(p mgl32.Vec3) cell {
+	if w.opts.Tolerance <= 0 {
+		return cell{int64(math.Float32bits(p[0])), int64(math.Float32bits(p[1])), int64(math.Float32bits(p[2]))}
+	}
+	t := float64(w.opts.Tolerance)
+	return cell{
+		int64(math.Floor(float64(p[0]) / t)),
+		int64(math.Floor(float64(p[1]) / t)),
+		int64(math.Floor(float64(p[2]) / t)),
+	}
+}
+
+// index returns the index of the welded vertex
+func (w *welder) index(p, normal mgl32.Vec3) uint32 {
+
+	c := w.cellOf(p)
+	r := int64(1)
+	if w.opts.Tolerance <= 0 {
+		r = 0
+	}
+	for dx := -r; dx <= r; dx++ {
+		for dy := -r; dy <= r; dy++ {
+			for dz := -r; dz <= r; dz++ {
+				for _, idx := range w.grid[cell{c.x + dx, c.y + dy, c.z + dz}] {
+					if w.mesh.Coords[idx].Sub(p).Len() > w.opts.Tolerance {
+						continue
+					}
+					if w.opts.SmoothNormals {
+						w.mesh.Normals[idx] = w.mesh.Normals[idx].Add(normal)
+						return idx
+					}
+					if w.mesh.Normals[idx].Sub(normal).Len() < 1e-4 {
+						return idx
+					}
+				}
+			}
+		}
+	}
+
+	idx := uint32(len(w.mesh.Coords))
+	w.mesh.Coords = append(w.mesh.Coords, p)
+	w.mesh.Normals = append(w.mesh.Normals, normal)
+	w.grid[c] = append(w.grid[c], idx)
+	return idx
+}
+
+func (w *welder) finish() rex.Mesh {
+	if w.opts.SmoothNormals {
+		for i, n := range w.mesh.Normals {
+			if n.Len() > 0 {
+				w.mesh.Normals[i] = n.Normalize()
+			}
+		}
+	}
+	return w.mesh
+}
@@ -0,0 +1,105 @@
+package stl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const asciiSTL = `solid square
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 1 1 0
+    endloop
+  endfacet
+  facet normal 0 0 0
+    outer loop
+      vertex 0 0 0
+      vertex 1 1 0.000001
+      vertex 0 1 0
+    endloop
+  endfacet
+  facet normal 0 -1 0
+    outer loop
+      vertex 0 0 0
+      vertex 0 0 1
+      vertex 1 0 0
+    endloop
+  endfacet
+endsolid square
+solid second
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 1 1 0
+    endloop
+  endfacet
+endsolid second
+`
+
+func TestDecodeASCII(t *testing.T) {
+
+	f, err := NewDecoder(strings.NewReader(asciiSTL)).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.Meshes) != 2 {
+		t.Fatalf("Expected 2 meshes, got %d", len(f.Meshes))
+	}
+	m := f.Meshes[0]
+	if m.Name != "square" || f.Meshes[1].Name != "second" || m.ID == f.Meshes[1].ID {
+		t.Errorf("Invalid meshes %s/%d %s/%d", m.Name, m.ID, f.Meshes[1].Name, f.Meshes[1].ID)
+	}
+	// the square shares 2 vertices (within the tolerance), the side facet has other normals
+	if len(m.Triangles) != 3 || len(m.Coords) != 7 {
+		t.Errorf("Expected 3 triangles and 7 vertices, got %d/%d", len(m.Triangles), len(m.Coords))
+	}
+	// the missing normal is computed
+	if n := m.Normals[m.Triangles[1].V2]; n.Sub(mgl32.Vec3{0, 0, 1}).Len() > 1e-5 {
+		t.Errorf("Expected computed normal, got %v", n)
+	}
+	if n := m.Normals[m.Triangles[2].V0]; n != (mgl32.Vec3{0, -1, 0}) {
+		t.Errorf("Expected facet normal, got %v", n)
+	}
+
+	// smooth normals weld all positions
+	f, err = NewDecoderWithOptions(strings.NewReader(asciiSTL), DecoderOptions{Tolerance: DefaultTolerance, SmoothNormals: true}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	m = f.Meshes[0]
+	if len(m.Coords) != 5 {
+		t.Errorf("Expected 5 vertices, got %d", len(m.Coords))
+	}
+	if n := m.Normals[0]; !mgl32.FloatEqualThreshold(n.Len(), 1, 1e-5) || n.Z() <= 0 || n.Y() >= 0 {
+		t.Errorf("Expected averaged normal, got %v", n)
+	}
+
+	// w/o tolerance the slightly different vertex is not welded
+	f, err = NewDecoderWithOptions(strings.NewReader(asciiSTL), DecoderOptions{}).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.Meshes[0].Coords) != 8 {
+		t.Errorf("Expected 8 vertices, got %d", len(f.Meshes[0].Coords))
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+
+	tests := []string{
+		"solid x\nfacet normal 0 0\nendsolid",
+		"solid x\nfacet normal 0 0 1\nvertex 0 0 0\nendfacet\nendsolid",
+		"solid x\nfacet normal 0 0 1\nvertex 0 a 0\nendfacet\nendsolid",
+		"short binary",
+	}
+	for _, data := range tests {
+		if _, err := NewDecoder(strings.NewReader(data)).Decode(); err == nil {
+			t.Errorf("Expected error for %q", data)
+		}
+	}
+}
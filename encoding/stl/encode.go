@@ -0,0 +1,100 @@
+package stl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+var (
+	errTooManyTriangles = errors.New("STL supports at most 2^32-1 triangles")
+	errInvalidIndex     = errors.New("triangle index out of range")
+)
+
+// Encoder writes REX meshes as binary STL file. The facet normals are
+// computed from the vertices, all other attributes are not supported by STL.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder creates a new binary STL encoder
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// WriteFile writes all meshes of the REX file into one binary STL file
+func WriteFile(name string, f rex.File) error {
+
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := NewEncoder(out).Encode(f); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// Encode writes all meshes of the REX file as one solid. The meshes are
+// written in their local coordinate system, scene nodes are not applied.
+func (enc *Encoder) Encode(f rex.File) error {
+	return enc.encode("", f.Meshes)
+}
+
+// EncodeMesh writes the mesh as solid
+func (enc *Encoder) EncodeMesh(m rex.Mesh) error {
+	return enc.encode(m.Name, []rex.Mesh{m})
+}
+
+func (enc *Encoder) encode(name string, meshes []rex.Mesh) error {
+
+	count := 0
+	for _, m := range meshes {
+		count += len(m.Triangles)
+	}
+	if int64(count) > math.MaxUint32 {
+		return errTooManyTriangles
+	}
+
+	// the header must not start with solid, otherwise the file may be detected as ASCII STL
+	var header [headerSize]byte
+	copy(header[:], headerPrefix+name)
+
+	w := bufio.NewWriter(enc.w)
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(count)); err != nil {
+		return err
+	}
+
+	var facet [facetSize]byte
+	for _, m := range meshes {
+		for _, t := range m.Triangles {
+			if int(t.V0) >= len(m.Coords) || int(t.V1) >= len(m.Coords) || int(t.V2) >= len(m.Coords) {
+				return errInvalidIndex
+			}
+			v := [4]mgl32.Vec3{{}, m.Coords[t.V0], m.Coords[t.V1], m.Coords[t.V2]}
+			if n := v[2].Sub(v[1]).Cross(v[3].Sub(v[1])); n.Len() > 0 {
+				v[0] = n.Normalize()
+			}
+			for j := range v {
+				for k := 0; k < 3; k++ {
+					binary.LittleEndian.PutUint32(facet[(j*3+k)*4:], math.Float32bits(v[j][k]))
+				}
+			}
+			if _, err := w.Write(facet[:]); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,99 @@
+package stl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+func cube() rex.Mesh {
+	return rex.Mesh{
+		ID:     1,
+		Name:   "cube",
+		Coords: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1}},
+		Triangles: []rex.Triangle{
+			{V0: 0, V1: 2, V2: 1}, {V0: 0, V1: 3, V2: 2},
+			{V0: 4, V1: 5, V2: 6}, {V0: 4, V1: 6, V2: 7},
+			{V0: 0, V1: 1, V2: 5}, {V0: 0, V1: 5, V2: 4},
+			{V0: 1, V1: 2, V2: 6}, {V0: 1, V1: 6, V2: 5},
+			{V0: 2, V1: 3, V2: 7}, {V0: 2, V1: 7, V2: 6},
+			{V0: 3, V1: 0, V2: 4}, {V0: 3, V1: 4, V2: 7},
+		},
+		MaterialID: rex.NotSpecified,
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).EncodeMesh(cube()); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if buf.Len() != headerSize+4+12*facetSize {
+		t.Fatalf("Invalid file size %d", buf.Len())
+	}
+
+	f, err := NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.Meshes) != 1 {
+		t.Fatalf("Expected 1 mesh, got %d", len(f.Meshes))
+	}
+	m := f.Meshes[0]
+	if m.Name != "cube" {
+		t.Errorf("Invalid name %q", m.Name)
+	}
+	// every side has its own normal -> 4 vertices per side
+	if len(m.Triangles) != 12 || len(m.Coords) != 24 {
+		t.Errorf("Expected 12 triangles and 24 vertices, got %d/%d", len(m.Triangles), len(m.Coords))
+	}
+	if n := m.Normals[m.Triangles[0].V0]; n != (mgl32.Vec3{0, 0, -1}) {
+		t.Errorf("Invalid normal %v", n)
+	}
+}
+
+func TestEncodeFile(t *testing.T) {
+
+	second := cube()
+	second.ID = 2
+	f := rex.File{Meshes: []rex.Mesh{cube(), second}}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(f); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if buf.Len() != headerSize+4+24*facetSize {
+		t.Fatalf("Invalid file size %d", buf.Len())
+	}
+
+	invalid := cube()
+	invalid.Triangles[0].V2 = 100
+	if err := NewEncoder(&buf).EncodeMesh(invalid); err == nil {
+		t.Errorf("Expected error for invalid index")
+	}
+}
+
+func TestEncodeName(t *testing.T) {
+
+	for _, name := range []string{"cube", "solid part", ""} {
+		m := cube()
+		m.Name = name
+		var buf bytes.Buffer
+		if err := NewEncoder(&buf).EncodeMesh(m); err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+		if bytes.HasPrefix(buf.Bytes(), []byte("solid")) {
+			t.Errorf("Header of %q starts with solid", name)
+		}
+		f, err := NewDecoder(&buf).Decode()
+		if err != nil {
+			t.Fatalf("TEST ERROR: %v", err)
+		}
+		if f.Meshes[0].Name != name {
+			t.Errorf("Expected name %q, got %q", name, f.Meshes[0].Name)
+		}
+	}
+}
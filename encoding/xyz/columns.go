@@ -0,0 +1,80 @@
+// Package xyz converts delimited text point clouds (XYZ, CSV) from and to REX point lists
+package xyz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// column indices of the supported values
+const (
+	colX = iota
+	colY
+	colZ
+	colR
+	colG
+	colB
+	numColumns
+)
+
+var columnNames = map[string]int{"x": colX, "y": colY, "z": colZ, "r": colR, "g": colG, "b": colB}
+
+// DefaultColumns are the columns of a point cloud w/o colors
+var DefaultColumns = []string{"x", "y", "z"}
+
+// ColorColumns are the columns of a point cloud with colors
+var ColorColumns = []string{"x", "y", "z", "r", "g", "b"}
+
+// ParseColumns splits a comma separated column list (e.g. "x,y,z,_,r,g,b")
+func ParseColumns(s string) []string {
+	columns := strings.Split(s, ",")
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+	return columns
+}
+
+// columnMapping contains the index of the text column of every value, -1 if not available
+type columnMapping struct {
+	index  [numColumns]int
+	colors bool
+	width  int // minimum number of values of a line
+}
+
+// newColumnMapping maps the column names to the values. Unknown names (e.g.
+// intensity or _) are skipped. x, y, z are required, colors are optional, but
+// require all of r, g, b.
+func newColumnMapping(columns []string) (*columnMapping, error) {
+
+	m := &columnMapping{}
+	for i := range m.index {
+		m.index[i] = -1
+	}
+	for i, name := range columns {
+		c, ok := columnNames[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		if m.index[c] >= 0 {
+			return nil, fmt.Errorf("Column %s is defined twice", name)
+		}
+		m.index[c] = i
+		if i+1 > m.width {
+			m.width = i + 1
+		}
+	}
+	if m.index[colX] < 0 || m.index[colY] < 0 || m.index[colZ] < 0 {
+		return nil, fmt.Errorf("Columns x, y and z are required")
+	}
+	colors := 0
+	for _, c := range []int{colR, colG, colB} {
+		if m.index[c] >= 0 {
+			colors++
+		}
+	}
+	if colors != 0 && colors != 3 {
+		return nil, fmt.Errorf("Columns r, g and b are required for colors")
+	}
+	m.colors = colors == 3
+	return m, nil
+}
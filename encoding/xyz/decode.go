@@ -0,0 +1,230 @@
+package xyz
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// DefaultChunkSize is the default maximum number of points of a point list
+const DefaultChunkSize = 1000000
+
+// DecoderOptions control the XYZ import
+type DecoderOptions struct {
+	// Columns contains the name of every column (x, y, z, r, g, b), other
+	// names are skipped. If empty, the columns are x,y,z or x,y,z,r,g,b
+	// depending on the number of values of the first line.
+	Columns []string
+	// Delimiter separates the values, if 0 whitespace, comma and semicolon are used
+	Delimiter rune
+	// SkipLines is the number of header lines which are skipped
+	SkipLines int
+	// Comment is the prefix of comment lines (e.g. #), empty lines are always skipped
+	Comment string
+	// UnitColors is true if the colors are given as 0..1 instead of 0..255
+	UnitColors bool
+	// Offset is subtracted from all coordinates and stored as offset of the
+	// coordinate system, this keeps the precision of large (e.g. UTM) coordinates.
+	Offset [3]float64
+	// ChunkSize is the maximum number of points of a point list, larger point
+	// clouds are split into several point lists. If 0 the DefaultChunkSize is used.
+	ChunkSize int
+}
+
+// Decoder reads delimited text point clouds line by line, therefore
+// arbitrary large files can be streamed with Next.
+type Decoder struct {
+	s    *bufio.Scanner
+	opts DecoderOptions
+
+	columns *columnMapping
+	origin  [3]float64 // offset of the coordinate system
+	line    int
+	pending []string // first data line which is read for the column detection
+	id      uint64
+	done    bool
+}
+
+// NewDecoder creates a new XYZ decoder with whitespace, comma or semicolon
+// separated values and # comments
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, DecoderOptions{Comment: "#"})
+}
+
+// NewDecoderWithOptions creates a new XYZ decoder with the given options
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	dec := &Decoder{s: s, opts: opts}
+	cs := dec.CoordinateSystem()
+	for i := 0; i < 3; i++ {
+		dec.origin[i] = float64(cs.Offset[i])
+	}
+	return dec
+}
+
+// ReadFile reads the given XYZ file with the default options
+func ReadFile(name string) (*rex.File, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewDecoder(f).Decode()
+}
+
+// CoordinateSystem returns the coordinate system of the point lists
+func (dec *Decoder) CoordinateSystem() rex.CoordinateSystem {
+	o := dec.opts.Offset
+	return rex.CoordinateSystem{Offset: mgl32.Vec3{float32(o[0]), float32(o[1]), float32(o[2])}}
+}
+
+// nextValues returns the values of the next data line, io.EOF at the end
+func (dec *Decoder) nextValues() ([]string, error) {
+
+	if dec.pending != nil {
+		values := dec.pending
+		dec.pending = nil
+		return values, nil
+	}
+	for dec.s.Scan() {
+		dec.line++
+		if dec.line <= dec.opts.SkipLines {
+			continue
+		}
+		line := strings.TrimSpace(dec.s.Text())
+		if line == "" || (dec.opts.Comment != "" && strings.HasPrefix(line, dec.opts.Comment)) {
+			continue
+		}
+		return dec.split(line), nil
+	}
+	if err := dec.s.Err(); err != nil {
+		return nil, fmt.Errorf("Reading line %d failed: %w", dec.line+1, err)
+	}
+	return nil, io.EOF
+}
+
+// split separates the values of the line
+func (dec *Decoder) split(line string) []string {
+
+	if dec.opts.Delimiter == 0 {
+		return strings.FieldsFunc(line, func(r rune) bool {
+			return unicode.IsSpace(r) || r == ',' || r == ';'
+		})
+	}
+	values := strings.Split(line, string(dec.opts.Delimiter))
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return values
+}
+
+// init creates the column mapping, the first data line is read if no columns are given
+func (dec *Decoder) init() error {
+
+	columns := dec.opts.Columns
+	if len(columns) == 0 {
+		values, err := dec.nextValues()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		dec.pending = values
+		columns = DefaultColumns
+		if len(values) >= len(ColorColumns) {
+			columns = ColorColumns
+		}
+	}
+	m, err := newColumnMapping(columns)
+	if err != nil {
+		return err
+	}
+	dec.columns = m
+	return nil
+}
+
+// Next returns the next point list with at most ChunkSize points. At the end io.EOF is returned.
+func (dec *Decoder) Next() (*rex.PointList, error) {
+
+	if dec.columns == nil {
+		if err := dec.init(); err != nil {
+			return nil, err
+		}
+	}
+	if dec.done {
+		return nil, io.EOF
+	}
+
+	m := dec.columns
+	colorMax := float32(255)
+	if dec.opts.UnitColors {
+		colorMax = 1
+	}
+	pl := &rex.PointList{}
+	for len(pl.Points) < dec.opts.ChunkSize {
+		values, err := dec.nextValues()
+		if err == io.EOF {
+			dec.done = true
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(values) < m.width {
+			return nil, fmt.Errorf("line %d: expected %d values, got %d", dec.line, m.width, len(values))
+		}
+
+		var v [numColumns]float64
+		for c, i := range m.index {
+			if i < 0 {
+				continue
+			}
+			if v[c], err = strconv.ParseFloat(values[i], 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %q", dec.line, values[i])
+			}
+		}
+		pl.Points = append(pl.Points, mgl32.Vec3{
+			float32(v[colX] - dec.origin[0]),
+			float32(v[colY] - dec.origin[1]),
+			float32(v[colZ] - dec.origin[2]),
+		})
+		if m.colors {
+			pl.Colors = append(pl.Colors, mgl32.Vec3{
+				mgl32.Clamp(float32(v[colR])/colorMax, 0, 1),
+				mgl32.Clamp(float32(v[colG])/colorMax, 0, 1),
+				mgl32.Clamp(float32(v[colB])/colorMax, 0, 1),
+			})
+		}
+	}
+
+	if len(pl.Points) == 0 {
+		return nil, io.EOF
+	}
+	dec.id++
+	pl.ID = dec.id
+	return pl, nil
+}
+
+// Decode reads all points and returns a REX file incl. the coordinate system
+func (dec *Decoder) Decode() (*rex.File, error) {
+
+	f := &rex.File{CoordinateSystem: dec.CoordinateSystem()}
+	for {
+		pl, err := dec.Next()
+		if err == io.EOF {
+			return f, nil
+		} else if err != nil {
+			return nil, err
+		}
+		f.PointLists = append(f.PointLists, *pl)
+	}
+}
@@ -0,0 +1,115 @@
+package xyz
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestDecodeDefault(t *testing.T) {
+
+	input := `# exported by scanner
+1 2 3 255 0 0
+
+4.5,5,6,0,51,255
+-1;-2;-3;0;0;0
+`
+	f, err := NewDecoder(strings.NewReader(input)).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.PointLists) != 1 {
+		t.Fatalf("Expected 1 point list, got %d", len(f.PointLists))
+	}
+	pl := f.PointLists[0]
+	if len(pl.Points) != 3 || len(pl.Colors) != 3 || pl.ID != 1 {
+		t.Fatalf("Expected 3 colored points, got %d/%d", len(pl.Points), len(pl.Colors))
+	}
+	if pl.Points[1] != (mgl32.Vec3{4.5, 5, 6}) || pl.Points[2] != (mgl32.Vec3{-1, -2, -3}) {
+		t.Errorf("Invalid points %v", pl.Points)
+	}
+	if pl.Colors[0] != (mgl32.Vec3{1, 0, 0}) || pl.Colors[1] != (mgl32.Vec3{0, 0.2, 1}) {
+		t.Errorf("Invalid colors %v", pl.Colors)
+	}
+}
+
+func TestDecodeOptions(t *testing.T) {
+
+	input := `X;Y;Z;Intensity;Red;Green;Blue
+header line 2
+500010.5;5000020.25;300;17;1;0.5;0
+// comment
+500011;5000021;301;18;0;1;0
+500012;5000022;302;19;0;0;1
+`
+	dec := NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{
+		Columns:    []string{"y", "x", "z", "intensity", "r", "g", "b"},
+		Delimiter:  ';',
+		SkipLines:  2,
+		Comment:    "//",
+		UnitColors: true,
+		Offset:     [3]float64{5000000, 500000, 0},
+		ChunkSize:  2,
+	})
+
+	f, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if f.CoordinateSystem.Offset != (mgl32.Vec3{5000000, 500000, 0}) {
+		t.Errorf("Invalid offset %v", f.CoordinateSystem.Offset)
+	}
+	if len(f.PointLists) != 2 || len(f.PointLists[0].Points) != 2 || len(f.PointLists[1].Points) != 1 {
+		t.Fatalf("Expected 2 point lists with 2 and 1 points")
+	}
+	if f.PointLists[1].ID != 2 {
+		t.Errorf("Invalid ID %d", f.PointLists[1].ID)
+	}
+	pl := f.PointLists[0]
+	if pl.Points[0] != (mgl32.Vec3{20.25, 10.5, 300}) {
+		t.Errorf("Invalid point %v", pl.Points[0])
+	}
+	if pl.Colors[0] != (mgl32.Vec3{1, 0.5, 0}) {
+		t.Errorf("Invalid color %v", pl.Colors[0])
+	}
+}
+
+func TestDecodeNoColors(t *testing.T) {
+
+	f, err := NewDecoder(strings.NewReader("1 2 3\n4 5 6\n")).Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(f.PointLists) != 1 || len(f.PointLists[0].Points) != 2 || f.PointLists[0].Colors != nil {
+		t.Errorf("Expected 2 points w/o colors")
+	}
+
+	// empty files have no point lists
+	f, err = NewDecoder(strings.NewReader("# nothing\n")).Decode()
+	if err != nil || len(f.PointLists) != 0 {
+		t.Errorf("Expected empty file, got %v", err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+
+	tests := []struct {
+		input   string
+		columns []string
+	}{
+		{"1 2\n", nil},
+		{"1 2 a\n", nil},
+		{"1 2 3\n", []string{"x", "y"}},
+		{"1 2 3 4\n", []string{"x", "y", "z", "r"}},
+		{"1 2 3\n", []string{"x", "x", "y", "z"}},
+		{"1 2 3\n1 2\n", []string{"x", "y", "z"}},
+	}
+	for _, test := range tests {
+		dec := NewDecoderWithOptions(strings.NewReader(test.input), DecoderOptions{Columns: test.columns})
+		if _, err := dec.Decode(); err == nil || err == io.EOF {
+			t.Errorf("Expected error for %q %v", test.input, test.columns)
+		}
+	}
+}
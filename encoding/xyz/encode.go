@@ -0,0 +1,151 @@
+package xyz
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/internal/coord"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+// EncoderOptions control the XYZ export
+type EncoderOptions struct {
+	// Columns contains the name of every column (x, y, z, r, g, b), other
+	// names are written as 0. If empty, the columns are x,y,z or x,y,z,r,g,b
+	// depending on the colors of the first point list.
+	Columns []string
+	// Delimiter separates the values, if 0 a space is used
+	Delimiter rune
+	// Header writes the column names as first line
+	Header bool
+	// UnitColors writes the colors as 0..1 instead of 0..255
+	UnitColors bool
+	// Offset is added to all coordinates (e.g. the offset of the coordinate system)
+	Offset [3]float64
+}
+
+// Encoder writes REX point lists as delimited text. Every call of
+// EncodePointList writes the points directly, therefore large point clouds
+// can be written list by list. Points w/o colors are written with black color.
+type Encoder struct {
+	w       *bufio.Writer
+	opts    EncoderOptions
+	columns *columnMapping
+	line    []byte
+}
+
+// NewEncoder creates a new XYZ encoder which writes space separated values
+func NewEncoder(w io.Writer) *Encoder {
+	return NewEncoderWithOptions(w, EncoderOptions{})
+}
+
+// NewEncoderWithOptions creates a new XYZ encoder with the given options
+func NewEncoderWithOptions(w io.Writer, opts EncoderOptions) *Encoder {
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ' '
+	}
+	return &Encoder{w: bufio.NewWriterSize(w, 64*1024), opts: opts}
+}
+
+// WriteFile writes all point lists of the REX file as space separated values.
+// The offset of the coordinate system is added to the points.
+func WriteFile(name string, f rex.File) error {
+
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	enc := NewEncoderWithOptions(out, EncoderOptions{Offset: coord.Offset(f.CoordinateSystem.Offset)})
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// Encode writes all point lists of the REX file and flushes the output
+func (enc *Encoder) Encode(f rex.File) error {
+
+	for _, pl := range f.PointLists {
+		if err := enc.EncodePointList(pl); err != nil {
+			return err
+		}
+	}
+	return enc.Flush()
+}
+
+// init creates the column mapping and writes the header
+func (enc *Encoder) init(pl rex.PointList) error {
+
+	columns := enc.opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultColumns
+		if len(pl.Colors) > 0 {
+			columns = ColorColumns
+		}
+	}
+	m, err := newColumnMapping(columns)
+	if err != nil {
+		return err
+	}
+	enc.opts.Columns = columns
+	enc.columns = m
+
+	if enc.opts.Header {
+		_, err = enc.w.WriteString(strings.Join(columns, string(enc.opts.Delimiter)) + "\n")
+	}
+	return err
+}
+
+// EncodePointList writes the points of the point list, Flush must be called at the end
+func (enc *Encoder) EncodePointList(pl rex.PointList) error {
+
+	if enc.columns == nil {
+		if err := enc.init(pl); err != nil {
+			return err
+		}
+	}
+	if len(pl.Colors) > 0 && len(pl.Colors) != len(pl.Points) {
+		return fmt.Errorf("Point list %d has %d points but %d colors", pl.ID, len(pl.Points), len(pl.Colors))
+	}
+
+	for i, p := range pl.Points {
+		var c mgl32.Vec3
+		if len(pl.Colors) > 0 {
+			c = pl.Colors[i]
+		}
+		enc.line = enc.line[:0]
+		for j, name := range enc.opts.Columns {
+			if j > 0 {
+				enc.line = append(enc.line, string(enc.opts.Delimiter)...)
+			}
+			col, ok := columnNames[strings.ToLower(name)]
+			switch {
+			case !ok:
+				enc.line = append(enc.line, '0')
+			case col <= colZ:
+				enc.line = coord.Append(enc.line, p[col], enc.opts.Offset[col])
+			case enc.opts.UnitColors:
+				enc.line = strconv.AppendFloat(enc.line, float64(mgl32.Clamp(c[col-colR], 0, 1)), 'f', -1, 32)
+			default:
+				enc.line = strconv.AppendInt(enc.line, int64(mgl32.Clamp(c[col-colR], 0, 1)*255+0.5), 10)
+			}
+		}
+		enc.line = append(enc.line, '\n')
+		if _, err := enc.w.Write(enc.line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes all buffered data
+func (enc *Encoder) Flush() error {
+	return enc.w.Flush()
+}
@@ -0,0 +1,140 @@
+package xyz
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/roboticeyes/gorex/encoding/rex"
+)
+
+func TestEncode(t *testing.T) {
+
+	f := rex.File{PointLists: []rex.PointList{
+		{ID: 1, Points: []mgl32.Vec3{{1.01, 2, -3.5}}, Colors: []mgl32.Vec3{{1, 0.2, 0}}},
+		{ID: 2, Points: []mgl32.Vec3{{0, 0, 0}}},
+	}}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(f); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	expected := "1.01 2 -3.5 255 51 0\n0 0 0 0 0 0\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestEncodeOptions(t *testing.T) {
+
+	pl := rex.PointList{ID: 1, Points: []mgl32.Vec3{{1.01, 2, 3}}, Colors: []mgl32.Vec3{{1, 0.5, 0}}}
+
+	var buf bytes.Buffer
+	enc := NewEncoderWithOptions(&buf, EncoderOptions{
+		Columns:    []string{"b", "g", "r", "intensity", "x", "y", "z"},
+		Delimiter:  ',',
+		Header:     true,
+		UnitColors: true,
+		Offset:     [3]float64{500000, 5000000, 0},
+	})
+	if err := enc.EncodePointList(pl); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	expected := "b,g,r,intensity,x,y,z\n0,0.5,1,0,500001.01,5000002,3\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestRoundtrip(t *testing.T) {
+
+	pl := rex.PointList{ID: 1, Points: []mgl32.Vec3{{1, 2, 3}, {-4.25, 5, 6}}, Colors: []mgl32.Vec3{{1, 0, 0}, {0, 0, 1}}}
+	f := rex.File{CoordinateSystem: rex.CoordinateSystem{Offset: mgl32.Vec3{100, 200, 0}}, PointLists: []rex.PointList{pl}}
+
+	var buf bytes.Buffer
+	enc := NewEncoderWithOptions(&buf, EncoderOptions{Offset: [3]float64{100, 200, 0}, Header: true})
+	if err := enc.Encode(f); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	dec := NewDecoderWithOptions(strings.NewReader(buf.String()), DecoderOptions{
+		SkipLines: 1,
+		Offset:    [3]float64{100, 200, 0},
+	})
+	res, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if len(res.PointLists) != 1 || len(res.PointLists[0].Points) != 2 {
+		t.Fatalf("Expected 1 point list with 2 points")
+	}
+	for i := range pl.Points {
+		if res.PointLists[0].Points[i] != pl.Points[i] || res.PointLists[0].Colors[i] != pl.Colors[i] {
+			t.Errorf("Point %d differs: %v %v", i, res.PointLists[0].Points[i], res.PointLists[0].Colors[i])
+		}
+	}
+}
+
+func TestRoundtripFractionalOffset(t *testing.T) {
+
+	pl := rex.PointList{ID: 1, Points: []mgl32.Vec3{{1.5, 0, 2}, {-0.125, 3.75, 0.5}}}
+	offset := mgl32.Vec3{0.25, 100.125, 0}
+
+	var buf bytes.Buffer
+	enc := NewEncoderWithOptions(&buf, EncoderOptions{Offset: [3]float64{0.25, 100.125, 0}})
+	if err := enc.Encode(rex.File{PointLists: []rex.PointList{pl}}); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	expected := "1.75 100.125 2\n0.125 103.875 0.5\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	dec := NewDecoderWithOptions(strings.NewReader(buf.String()), DecoderOptions{Offset: [3]float64{0.25, 100.125, 0}})
+	res, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	if res.CoordinateSystem.Offset != offset {
+		t.Errorf("Invalid offset %v", res.CoordinateSystem.Offset)
+	}
+	for i, p := range pl.Points {
+		if res.PointLists[0].Points[i] != p {
+			t.Errorf("Expected %v, got %v", p, res.PointLists[0].Points[i])
+		}
+	}
+}
+
+func TestRoundtripInexactOffset(t *testing.T) {
+
+	// 500000.1 cannot be represented as float32, the exported values must not be biased
+	input := "500000.1 5000000.2 10.3\n500012.34 4999990.05 0\n"
+	dec := NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{Offset: [3]float64{500000.1, 5000000.2, 0}})
+	res, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+
+	out, err := ioutil.TempFile("", "roundtrip*.xyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+	if err := WriteFile(out.Name(), *res); err != nil {
+		t.Fatalf("TEST ERROR: %v", err)
+	}
+	data, err := ioutil.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != input {
+		t.Errorf("Expected %q, got %q", input, data)
+	}
+}